@@ -16,13 +16,13 @@ import (
 	"github.com/openshift/csi-driver-manila-operator/pkg/controller"
 	"github.com/openshift/csi-driver-manila-operator/version"
 
+	configv1 "github.com/openshift/api/config/v1"
 	securityv1 "github.com/openshift/api/security/v1"
 	credsv1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	storagev1 "k8s.io/api/storage/v1"
-	storagev1beta1 "k8s.io/api/storage/v1beta1"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	kubemetrics "github.com/operator-framework/operator-sdk/pkg/kube-metrics"
@@ -55,8 +55,8 @@ func init() {
 	corev1.AddToScheme(scheme)
 	rbacv1.AddToScheme(scheme)
 	storagev1.AddToScheme(scheme)
-	storagev1beta1.AddToScheme(scheme)
 	securityv1.AddToScheme(scheme)
+	configv1.AddToScheme(scheme)
 }
 
 func printVersion() {
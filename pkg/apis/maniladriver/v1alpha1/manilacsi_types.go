@@ -1,15 +1,330 @@
 package v1alpha1
 
 import (
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+const (
+	// ConditionDegraded indicates that the operator was not able to reconcile the driver
+	ConditionDegraded status.ConditionType = "Degraded"
+
+	// ConditionDisabled indicates that the driver is not deployed, because the cluster doesn't run on OpenStack
+	ConditionDisabled status.ConditionType = "Disabled"
+
+	// ConditionShareNetworkMissing indicates that StorageClasses of share types with
+	// driver_handles_share_servers=true are not created, because no share network is configured
+	ConditionShareNetworkMissing status.ConditionType = "ShareNetworkMissing"
+)
+
+// Preflight conditions report results of the checks that the operator runs against OpenStack
+// before deploying the driver. Each of them is true when the check passed.
+const (
+	// ConditionPreflightTLSTrust indicates that the certificates of OpenStack endpoints are trusted
+	ConditionPreflightTLSTrust status.ConditionType = "PreflightTLSTrust"
+
+	// ConditionPreflightKeystoneAuth indicates that the credentials were accepted by Keystone
+	ConditionPreflightKeystoneAuth status.ConditionType = "PreflightKeystoneAuth"
+
+	// ConditionPreflightManilaEndpoint indicates that Manila is present in the service catalog
+	ConditionPreflightManilaEndpoint status.ConditionType = "PreflightManilaEndpoint"
+
+	// ConditionPreflightManilaAPIVersion indicates that Manila supports the required API version
+	ConditionPreflightManilaAPIVersion status.ConditionType = "PreflightManilaAPIVersion"
+
+	// ConditionPreflightShareTypes indicates that share types can be listed
+	ConditionPreflightShareTypes status.ConditionType = "PreflightShareTypes"
+
+	// ConditionPreflightQuotas indicates that project quotas can be read and allow creating shares
+	ConditionPreflightQuotas status.ConditionType = "PreflightQuotas"
+)
+
+// CredentialsSourceType defines where the operator takes OpenStack credentials from
+type CredentialsSourceType string
+
+const (
+	// CredentialsSourceCloudCredentialOperator requests the credentials from the Cloud Credential Operator
+	CredentialsSourceCloudCredentialOperator CredentialsSourceType = "CloudCredentialOperator"
+
+	// CredentialsSourceCloudsYAML reads the credentials from clouds.yaml stored in a user provided secret
+	CredentialsSourceCloudsYAML CredentialsSourceType = "CloudsYAML"
+
+	// CredentialsSourceDriverSecret reads the credentials from a user provided secret with the
+	// driver os-* keys
+	CredentialsSourceDriverSecret CredentialsSourceType = "DriverSecret"
+)
+
+// CredentialsSpec defines OpenStack credentials used by the operator and the driver
+type CredentialsSpec struct {
+	// Source defines where the credentials are taken from.
+	// Defaults to "CloudCredentialOperator".
+	// +kubebuilder:validation:Enum=CloudCredentialOperator;CloudsYAML;DriverSecret
+	// +optional
+	Source CredentialsSourceType `json:"source,omitempty"`
+
+	// SecretRef references the secret with the credentials.
+	// Required for "CloudsYAML" and "DriverSecret" sources.
+	// +optional
+	SecretRef *corev1.SecretReference `json:"secretRef,omitempty"`
+}
+
+// ShareNetworkSpec defines the Manila share network used by share types that handle share servers
+type ShareNetworkSpec struct {
+	// ID is the ID of the Manila share network
+	// +optional
+	ID string `json:"id,omitempty"`
+
+	// NeutronNetID is the ID of the cluster Neutron network. When ID is not set,
+	// the share network attached to this network is used.
+	// +optional
+	NeutronNetID string `json:"neutronNetID,omitempty"`
+
+	// NeutronSubnetID is the ID of the cluster Neutron subnet. When ID is not set,
+	// the share network attached to this subnet is used.
+	// +optional
+	NeutronSubnetID string `json:"neutronSubnetID,omitempty"`
+}
+
+// TopologySpec defines topology-aware provisioning of shares
+type TopologySpec struct {
+	// Enabled makes the driver aware of Manila availability zones, so shares are created
+	// in the availability zone of the nodes that use them
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// StorageClassPerZone additionally creates a StorageClass for each share type
+	// and Manila availability zone
+	// +optional
+	StorageClassPerZone bool `json:"storageClassPerZone,omitempty"`
+}
+
+// SnapshotDeletionPolicy defines what happens to Manila snapshots when their VolumeSnapshotContent is deleted
+type SnapshotDeletionPolicy string
+
+const (
+	// SnapshotDeletionPolicyDelete deletes Manila snapshots along with their VolumeSnapshotContents
+	SnapshotDeletionPolicyDelete SnapshotDeletionPolicy = "Delete"
+
+	// SnapshotDeletionPolicyRetain keeps Manila snapshots when their VolumeSnapshotContents are deleted
+	SnapshotDeletionPolicyRetain SnapshotDeletionPolicy = "Retain"
+)
+
+// SnapshotsSpec defines VolumeSnapshotClasses created by the operator
+type SnapshotsSpec struct {
+	// DeletionPolicy of the VolumeSnapshotClasses.
+	// Defaults to "Delete".
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// +optional
+	DeletionPolicy SnapshotDeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// SingleClass creates one default VolumeSnapshotClass instead of a VolumeSnapshotClass
+	// for each share type that supports snapshots
+	// +optional
+	SingleClass bool `json:"singleClass,omitempty"`
+}
+
+// ProxySpec defines the HTTP proxy used to reach OpenStack
+type ProxySpec struct {
+	// HTTPProxy is the URL of the proxy for HTTP requests
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// HTTPSProxy is the URL of the proxy for HTTPS requests
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// NoProxy is a comma-separated list of hostnames and CIDRs for which the proxy should not be used
+	// +optional
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
+// NFSSpec defines the mount options of NFS volumes
+type NFSSpec struct {
+	// MountOptions are set on the StorageClasses of all share types, for example "nfsvers=4.1"
+	// +optional
+	MountOptions []string `json:"mountOptions,omitempty"`
+
+	// ShareTypes defines mount options of specific share types. They override the cluster-wide
+	// mount options with the same name.
+	// +optional
+	ShareTypes []ShareTypeNFSSpec `json:"shareTypes,omitempty"`
+}
+
+// ShareTypeNFSSpec defines the mount options of NFS volumes of a share type
+type ShareTypeNFSSpec struct {
+	// Name of the Manila share type
+	Name string `json:"name"`
+
+	// MountOptions are set on the StorageClasses of the share type
+	// +optional
+	MountOptions []string `json:"mountOptions,omitempty"`
+}
+
+// NodeUpdateStrategySpec defines how the node plugin DaemonSets are updated
+type NodeUpdateStrategySpec struct {
+	// MaxUnavailable is the maximum number of node plugin pods that can be unavailable during the update.
+	// Value can be an absolute number or a percentage of the nodes. Defaults to 1.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// CanaryNodeSelector selects the nodes that are updated first. The node plugins on the other
+	// nodes are updated only after the pods on the canary nodes become ready.
+	// +optional
+	CanaryNodeSelector map[string]string `json:"canaryNodeSelector,omitempty"`
+}
+
+// FSGroupPolicy defines if the volumes support ownership and permission changes by fsGroup
+type FSGroupPolicy string
+
+const (
+	// FSGroupPolicyReadWriteOnceWithFSType changes ownership only of the volumes with fsType and ReadWriteOnce access mode
+	FSGroupPolicyReadWriteOnceWithFSType FSGroupPolicy = "ReadWriteOnceWithFSType"
+
+	// FSGroupPolicyFile always changes ownership and permissions of the volumes
+	FSGroupPolicyFile FSGroupPolicy = "File"
+
+	// FSGroupPolicyNone never changes ownership and permissions of the volumes
+	FSGroupPolicyNone FSGroupPolicy = "None"
+)
+
+// VolumeLifecycleMode is a mode in which the driver provides volumes
+// +kubebuilder:validation:Enum=Persistent;Ephemeral
+type VolumeLifecycleMode string
+
+const (
+	// VolumeLifecyclePersistent provides volumes through PersistentVolumes
+	VolumeLifecyclePersistent VolumeLifecycleMode = "Persistent"
+
+	// VolumeLifecycleEphemeral provides inline ephemeral volumes of pods
+	VolumeLifecycleEphemeral VolumeLifecycleMode = "Ephemeral"
+)
+
+// CSIDriverSpec defines the settings of the CSIDriver object of the driver
+type CSIDriverSpec struct {
+	// FSGroupPolicy defines if ownership and permissions of the volumes are changed by fsGroup of pods.
+	// It's supported by Kubernetes 1.19 and newer. Defaults to the Kubernetes default.
+	// +kubebuilder:validation:Enum=ReadWriteOnceWithFSType;File;None
+	// +optional
+	FSGroupPolicy FSGroupPolicy `json:"fsGroupPolicy,omitempty"`
+
+	// VolumeLifecycleModes are the modes in which the driver provides volumes.
+	// Defaults to "Persistent".
+	// +optional
+	VolumeLifecycleModes []VolumeLifecycleMode `json:"volumeLifecycleModes,omitempty"`
+
+	// RequiresRepublish makes kubelet call NodePublishVolume periodically on mounted volumes.
+	// It's supported by Kubernetes 1.20 and newer.
+	// +optional
+	RequiresRepublish bool `json:"requiresRepublish,omitempty"`
+}
+
 // ManilaDriverSpec defines the desired state of ManilaDriver
 type ManilaDriverSpec struct {
+	// CloudName is the name of the entry in clouds.yaml that contains credentials for the driver.
+	// Defaults to "openstack".
+	// +optional
+	CloudName string `json:"cloudName,omitempty"`
+
+	// Credentials defines where OpenStack credentials are taken from
+	// +optional
+	Credentials *CredentialsSpec `json:"credentials,omitempty"`
+
+	// ShareNetwork defines the share network for share types with driver_handles_share_servers=true.
+	// StorageClasses of these share types are not created without it. When it's empty, the share network
+	// attached to the Neutron network of the cluster nodes is used.
+	// +optional
+	ShareNetwork *ShareNetworkSpec `json:"shareNetwork,omitempty"`
+
+	// Topology defines topology-aware provisioning of shares
+	// +optional
+	Topology *TopologySpec `json:"topology,omitempty"`
+
+	// Snapshots defines VolumeSnapshotClasses created by the operator
+	// +optional
+	Snapshots *SnapshotsSpec `json:"snapshots,omitempty"`
+
+	// VolumeExpansion deploys the external-resizer sidecar and allows expanding volumes of all share types
+	// when the Manila API supports extending shares. Manila doesn't report which backends can extend shares,
+	// so enable it only if the backends of all share types support it.
+	// +optional
+	VolumeExpansion bool `json:"volumeExpansion,omitempty"`
+
+	// ControllerReplicas is the number of controller plugin replicas.
+	// Defaults to 2.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ControllerReplicas *int32 `json:"controllerReplicas,omitempty"`
+
+	// Proxy overrides the cluster-wide proxy configuration for the operator and the driver
+	// +optional
+	Proxy *ProxySpec `json:"proxy,omitempty"`
+
+	// NFS defines the mount options of NFS volumes
+	// +optional
+	NFS *NFSSpec `json:"nfs,omitempty"`
+
+	// UpdateStrategy defines how the node plugin DaemonSets are updated
+	// +optional
+	UpdateStrategy *NodeUpdateStrategySpec `json:"updateStrategy,omitempty"`
+
+	// SkipPlatformCheck deploys the driver even if the Infrastructure object doesn't report the OpenStack
+	// platform. Set it on OpenStack clusters installed with platform "None".
+	// +optional
+	SkipPlatformCheck bool `json:"skipPlatformCheck,omitempty"`
+
+	// CSIDriver defines the settings of the CSIDriver object of the driver
+	// +optional
+	CSIDriver *CSIDriverSpec `json:"csiDriver,omitempty"`
+}
+
+// ManilaCapabilities describes the features of the Manila service detected by the operator
+type ManilaCapabilities struct {
+	// Microversion is the Manila API microversion negotiated with the cloud
+	Microversion string `json:"microversion"`
+
+	// Snapshots reports whether shares can be snapshotted
+	Snapshots bool `json:"snapshots"`
+
+	// CreateShareFromSnapshot reports whether new shares can be created from snapshots
+	CreateShareFromSnapshot bool `json:"createShareFromSnapshot"`
+
+	// RevertToSnapshot reports whether shares can be reverted to their latest snapshot
+	RevertToSnapshot bool `json:"revertToSnapshot"`
+
+	// ExtendShare reports whether shares can be extended
+	ExtendShare bool `json:"extendShare"`
+
+	// ShrinkShare reports whether shares can be shrunk
+	ShrinkShare bool `json:"shrinkShare"`
+
+	// AccessMetadata reports whether metadata can be set on share access rules
+	AccessMetadata bool `json:"accessMetadata"`
 }
 
 // ManilaDriverStatus defines the observed state of ManilaDriver
 type ManilaDriverStatus struct {
+	// Conditions describe the current state of the driver deployment
+	// +optional
+	Conditions status.Conditions `json:"conditions,omitempty"`
+
+	// LastRotationTime is the last time the driver pods were restarted because
+	// the driver credentials or CA bundle changed
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+
+	// Capabilities are the features of the Manila service detected by the operator
+	// +optional
+	Capabilities *ManilaCapabilities `json:"capabilities,omitempty"`
+
+	// ShareNetworkID is the ID of the share network used by the StorageClasses
+	// +optional
+	ShareNetworkID string `json:"shareNetworkID,omitempty"`
+
+	// AvailabilityZones are the Manila availability zones used by the StorageClasses
+	// +optional
+	AvailabilityZones []string `json:"availabilityZones,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -2,6 +2,7 @@ package maniladriver
 
 import (
 	"context"
+	"strings"
 
 	"github.com/go-logr/logr"
 	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
@@ -9,31 +10,137 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+const (
+	cloudProviderConfigName      = "cloud-provider-config"
+	cloudProviderConfigNamespace = "openshift-config"
+	cloudProviderCAKey           = "ca-bundle.pem"
+
+	// trustedCABundleName is the ConfigMap the Cluster Network Operator injects the cluster trusted CA bundle into
+	trustedCABundleName  = "manila-csi-trusted-ca-bundle"
+	trustedCABundleKey   = "ca-bundle.crt"
+	trustedCABundleLabel = "config.openshift.io/inject-trusted-cabundle"
+
+	caCertConfigMapName = "openstack-certificates"
+	caCertKey           = "cloud-provider-ca-bundle.pem"
+)
+
+// getCloudProviderCert returns the CA certificate of the cloud, or an empty string if the cloud
+// provider config doesn't exist or has no certificate
 func (r *ReconcileManilaDriver) getCloudProviderCert() (string, error) {
 	cm := &corev1.ConfigMap{}
-	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: "cloud-provider-config", Namespace: "openshift-config"}, cm)
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: cloudProviderConfigName, Namespace: cloudProviderConfigNamespace}, cm)
 	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
 		return "", err
 	}
 
-	return string(cm.Data["ca-bundle.pem"]), nil
+	return cm.Data[cloudProviderCAKey], nil
+}
+
+// getTrustedCABundle returns the cluster trusted CA bundle injected into the driver namespace,
+// or an empty string if it hasn't been injected
+func (r *ReconcileManilaDriver) getTrustedCABundle() (string, error) {
+	cm := &corev1.ConfigMap{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: trustedCABundleName, Namespace: secretNamespace}, cm)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return cm.Data[trustedCABundleKey], nil
+}
+
+// getCABundle returns the CA certificates used to connect to OpenStack: the CA certificate of the cloud
+// combined with the cluster trusted CA bundle
+func (r *ReconcileManilaDriver) getCABundle() (string, error) {
+	cert, err := r.getCloudProviderCert()
+	if err != nil {
+		return "", err
+	}
+
+	trustedCABundle, err := r.getTrustedCABundle()
+	if err != nil {
+		return "", err
+	}
+
+	var certs []string
+	for _, c := range []string{cert, trustedCABundle} {
+		c = strings.TrimSpace(c)
+		if c != "" {
+			certs = append(certs, c)
+		}
+	}
+
+	if len(certs) == 0 {
+		return "", nil
+	}
+
+	return strings.Join(certs, "\n") + "\n", nil
+}
+
+// handleTrustedCABundleConfigMap creates the ConfigMap the cluster trusted CA bundle is injected into.
+// Its data is managed by the Cluster Network Operator, so the operator only makes sure the label is set.
+func (r *ReconcileManilaDriver) handleTrustedCABundleConfigMap(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) error {
+	reqLogger.Info("Reconciling Trusted CA Bundle ConfigMap")
+
+	cm := generateTrustedCABundleConfigMap()
+
+	found := &corev1.ConfigMap{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		reqLogger.Info("Creating a new ConfigMap", "ConfigMap.Namespace", cm.Namespace, "ConfigMap.Name", cm.Name)
+		return r.client.Create(context.TODO(), cm)
+	} else if err != nil {
+		return err
+	}
+
+	if found.Labels[trustedCABundleLabel] != "true" {
+		reqLogger.Info("Updating ConfigMap with new changes", "ConfigMap.Namespace", found.Namespace, "ConfigMap.Name", found.Name)
+		if found.Labels == nil {
+			found.Labels = map[string]string{}
+		}
+		found.Labels[trustedCABundleLabel] = "true"
+		return r.client.Update(context.TODO(), found)
+	}
+
+	// ConfigMap already exists - don't requeue
+	reqLogger.Info("Skip reconcile: ConfigMap already exists", "ConfigMap.Namespace", found.Namespace, "ConfigMap.Name", found.Name)
+	return nil
 }
 
-// handleCACertConfigMap converts the cloud provider configmap with the ca cert, if it is available,
-// into the driver configmap
+func generateTrustedCABundleConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      trustedCABundleName,
+			Namespace: secretNamespace,
+			Labels: map[string]string{
+				trustedCABundleLabel: "true",
+			},
+		},
+	}
+}
+
+// handleCACertConfigMap combines the CA certificate of the cloud and the cluster trusted CA bundle, if they are
+// available, into the driver configmap. The driver configmap is removed when there are no certificates anymore.
 func (r *ReconcileManilaDriver) handleCACertConfigMap(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) error {
 	reqLogger.Info("Reconciling CA Cert ConfigMap")
 
-	cert, err := r.getCloudProviderCert()
+	cert, err := r.getCABundle()
 	if err != nil {
 		return err
 	}
 
-	// We don't have the certificate, so we don't need to create the configmap in for the driver
+	// We don't have the certificates, so the driver doesn't need the configmap
 	if cert == "" {
-		return nil
+		return r.deleteCACertConfigMap(reqLogger)
 	}
 
 	cm := generateCACertConfigMap(cert)
@@ -69,7 +176,7 @@ func (r *ReconcileManilaDriver) handleCACertConfigMap(instance *maniladriverv1al
 		return err
 	}
 
-	// Convert the cloud provider configmap with the ca cert into driver configmap
+	// Convert the CA certificates into driver configmap
 	reqLogger.Info("Creating a new ConfigMap", "ConfigMap.Namespace", cm.Namespace, "ConfigMap.Name", cm.Name)
 	err = r.client.Create(context.TODO(), cm)
 	if err != nil {
@@ -80,14 +187,49 @@ func (r *ReconcileManilaDriver) handleCACertConfigMap(instance *maniladriverv1al
 	return nil
 }
 
+func (r *ReconcileManilaDriver) deleteCACertConfigMap(reqLogger logr.Logger) error {
+	cm := generateCACertConfigMap("")
+
+	err := r.client.Delete(context.TODO(), cm)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	reqLogger.Info("ConfigMap was deleted succesfully", "ConfigMap.Namespace", cm.Namespace, "ConfigMap.Name", cm.Name)
+
+	return nil
+}
+
 func generateCACertConfigMap(cert string) *corev1.ConfigMap {
 	cm := corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "openstack-certificates",
-			Namespace: "openshift-manila-csi-driver",
+			Name:      caCertConfigMapName,
+			Namespace: secretNamespace,
 		},
-		Data: map[string]string{"cloud-provider-ca-bundle.pem": cert},
+		Data: map[string]string{caCertKey: cert},
 	}
 
 	return &cm
 }
+
+// caBundleConfigMapMapper enqueues the ManilaDriver when one of the sources of the CA certificates changes
+type caBundleConfigMapMapper struct{}
+
+var _ handler.Mapper = &caBundleConfigMapMapper{}
+
+// Map implements handler.Mapper
+func (m *caBundleConfigMapMapper) Map(obj handler.MapObject) []reconcile.Request {
+	name := types.NamespacedName{Name: obj.Meta.GetName(), Namespace: obj.Meta.GetNamespace()}
+	switch name {
+	case types.NamespacedName{Name: cloudProviderConfigName, Namespace: cloudProviderConfigNamespace},
+		types.NamespacedName{Name: trustedCABundleName, Namespace: secretNamespace}:
+		return []reconcile.Request{
+			{NamespacedName: types.NamespacedName{Name: manilaDriverCRName}},
+		}
+	}
+
+	return nil
+}
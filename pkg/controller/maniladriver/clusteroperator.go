@@ -0,0 +1,277 @@
+package maniladriver
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	"github.com/openshift/csi-driver-manila-operator/version"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// clusterOperatorName is the name of the ClusterOperator the operator reports its status to
+	clusterOperatorName = "manila-csi-driver"
+
+	reasonManilaDriverNotFound  = "ManilaDriverNotFound"
+	reasonDriverNotDeployed     = "DriverNotDeployed"
+	reasonDriverRollingOut      = "DriverRollingOut"
+	reasonDriverPodsUnavailable = "DriverPodsUnavailable"
+)
+
+// syncClusterOperator reports the result of the reconciliation in the ClusterOperator.
+// The ClusterOperator API is only available on OpenShift, so nothing is reported on other clusters.
+func (r *ReconcileManilaDriver) syncClusterOperator(reqLogger logr.Logger) error {
+	co := &configv1.ClusterOperator{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: clusterOperatorName}, co)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		reqLogger.Info("Creating a new ClusterOperator", "ClusterOperator.Name", clusterOperatorName)
+		co = &configv1.ClusterOperator{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: clusterOperatorName,
+			},
+		}
+		err = r.client.Create(context.TODO(), co)
+		if err != nil {
+			return err
+		}
+	}
+
+	newStatus, err := r.generateClusterOperatorStatus(co.Status)
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(co.Status, *newStatus) {
+		return nil
+	}
+
+	reqLogger.Info("Updating ClusterOperator status", "ClusterOperator.Name", clusterOperatorName)
+	co.Status = *newStatus
+	return r.client.Status().Update(context.TODO(), co)
+}
+
+// generateClusterOperatorStatus derives the ClusterOperator conditions from the ManilaDriver status
+// and the state of the driver pods
+func (r *ReconcileManilaDriver) generateClusterOperatorStatus(current configv1.ClusterOperatorStatus) (*configv1.ClusterOperatorStatus, error) {
+	newStatus := current.DeepCopy()
+
+	newStatus.RelatedObjects = []configv1.ObjectReference{
+		{Resource: "namespaces", Name: secretNamespace},
+		{Group: maniladriverv1alpha1.SchemeGroupVersion.Group, Resource: "maniladrivers", Name: manilaDriverCRName},
+		{Group: "storage.k8s.io", Resource: "csidrivers", Name: "manila.csi.openstack.org"},
+	}
+
+	instance := &maniladriverv1alpha1.ManilaDriver{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: manilaDriverCRName}, instance)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if errors.IsNotFound(err) || instance.GetDeletionTimestamp() != nil {
+		message := fmt.Sprintf("ManilaDriver %v doesn't exist", manilaDriverCRName)
+		setClusterOperatorCondition(newStatus, configv1.OperatorAvailable, configv1.ConditionFalse, reasonManilaDriverNotFound, message)
+		setClusterOperatorCondition(newStatus, configv1.OperatorProgressing, configv1.ConditionFalse, reasonManilaDriverNotFound, message)
+		setClusterOperatorCondition(newStatus, configv1.OperatorDegraded, configv1.ConditionFalse, reasonAsExpected, "")
+		setClusterOperatorCondition(newStatus, configv1.OperatorUpgradeable, configv1.ConditionTrue, reasonAsExpected, "")
+		return newStatus, nil
+	}
+
+	// The driver is not deployed on other platforms than OpenStack, which is not an error
+	disabled := instance.Status.Conditions.GetCondition(maniladriverv1alpha1.ConditionDisabled)
+	if disabled != nil && disabled.IsTrue() {
+		setClusterOperatorCondition(newStatus, configv1.OperatorAvailable, configv1.ConditionTrue, string(disabled.Reason), disabled.Message)
+		setClusterOperatorCondition(newStatus, configv1.OperatorProgressing, configv1.ConditionFalse, string(disabled.Reason), disabled.Message)
+		setClusterOperatorCondition(newStatus, configv1.OperatorDegraded, configv1.ConditionFalse, reasonAsExpected, "")
+		setClusterOperatorCondition(newStatus, configv1.OperatorUpgradeable, configv1.ConditionTrue, reasonAsExpected, "")
+		newStatus.Versions = []configv1.OperandVersion{
+			{Name: "operator", Version: version.Version},
+		}
+		return newStatus, nil
+	}
+
+	// Degraded is taken only from the persisted condition of the ManilaDriver. Errors of a single reconcile
+	// are often transient and are retried, so they don't mark the ClusterOperator as degraded.
+	degraded := instance.Status.Conditions.GetCondition(maniladriverv1alpha1.ConditionDegraded)
+	if degraded != nil && degraded.IsTrue() {
+		setClusterOperatorCondition(newStatus, configv1.OperatorDegraded, configv1.ConditionTrue, string(degraded.Reason), degraded.Message)
+	} else {
+		setClusterOperatorCondition(newStatus, configv1.OperatorDegraded, configv1.ConditionFalse, reasonAsExpected, "")
+	}
+
+	// Available and Progressing
+	available, progressing, err := r.getDriverRolloutState()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(available) == 0 {
+		setClusterOperatorCondition(newStatus, configv1.OperatorAvailable, configv1.ConditionFalse, reasonDriverNotDeployed, "The driver is not deployed")
+	} else if unavailable := available.unavailable(); len(unavailable) > 0 {
+		setClusterOperatorCondition(newStatus, configv1.OperatorAvailable, configv1.ConditionFalse, reasonDriverPodsUnavailable,
+			fmt.Sprintf("No pods of %v are available", strings.Join(unavailable, ", ")))
+	} else {
+		setClusterOperatorCondition(newStatus, configv1.OperatorAvailable, configv1.ConditionTrue, reasonAsExpected, "")
+		// The operands don't have versions of their own, they are released with the operator
+		newStatus.Versions = []configv1.OperandVersion{
+			{Name: "operator", Version: version.Version},
+		}
+	}
+
+	if len(progressing) > 0 {
+		setClusterOperatorCondition(newStatus, configv1.OperatorProgressing, configv1.ConditionTrue, reasonDriverRollingOut,
+			fmt.Sprintf("Rolling out %v", strings.Join(progressing, ", ")))
+	} else {
+		setClusterOperatorCondition(newStatus, configv1.OperatorProgressing, configv1.ConditionFalse, reasonAsExpected, "")
+	}
+
+	setClusterOperatorCondition(newStatus, configv1.OperatorUpgradeable, configv1.ConditionTrue, reasonAsExpected, "")
+
+	return newStatus, nil
+}
+
+// driverAvailability maps the driver workloads to whether any of their pods is available
+type driverAvailability map[string]bool
+
+func (a driverAvailability) unavailable() []string {
+	var names []string
+	for name, available := range a {
+		if !available {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getDriverRolloutState returns availability of the deployed driver workloads and the ones that are being rolled out
+func (r *ReconcileManilaDriver) getDriverRolloutState() (driverAvailability, []string, error) {
+	available := driverAvailability{}
+	var progressing []string
+
+	deployment := &appsv1.Deployment{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: controllerPluginDeploymentName, Namespace: secretNamespace}, deployment)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, nil, err
+	}
+	if err == nil {
+		available["Deployment "+deployment.Name] = deployment.Status.AvailableReplicas > 0
+		if isDeploymentProgressing(deployment) {
+			progressing = append(progressing, "Deployment "+deployment.Name)
+		}
+	}
+
+	for _, name := range nodePluginDaemonSetNames {
+		ds := &appsv1.DaemonSet{}
+		err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: secretNamespace}, ds)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, nil, err
+		}
+
+		available["DaemonSet "+ds.Name] = ds.Status.DesiredNumberScheduled == 0 || ds.Status.NumberAvailable > 0
+		if isDaemonSetProgressing(ds) {
+			progressing = append(progressing, "DaemonSet "+ds.Name)
+		}
+	}
+
+	return available, progressing, nil
+}
+
+func isDeploymentProgressing(deployment *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	return deployment.Status.ObservedGeneration < deployment.Generation ||
+		deployment.Status.UpdatedReplicas < replicas ||
+		deployment.Status.AvailableReplicas < replicas
+}
+
+func isDaemonSetProgressing(ds *appsv1.DaemonSet) bool {
+	return ds.Status.ObservedGeneration < ds.Generation ||
+		ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled ||
+		ds.Status.NumberAvailable < ds.Status.DesiredNumberScheduled
+}
+
+// setClusterOperatorCondition sets the condition and updates its transition time only when its status changes
+func setClusterOperatorCondition(coStatus *configv1.ClusterOperatorStatus, conditionType configv1.ClusterStatusConditionType, conditionStatus configv1.ConditionStatus, reason, message string) {
+	condition := configv1.ClusterOperatorStatusCondition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+
+	for i := range coStatus.Conditions {
+		if coStatus.Conditions[i].Type != conditionType {
+			continue
+		}
+		if coStatus.Conditions[i].Status == conditionStatus {
+			condition.LastTransitionTime = coStatus.Conditions[i].LastTransitionTime
+		}
+		coStatus.Conditions[i] = condition
+		return
+	}
+
+	coStatus.Conditions = append(coStatus.Conditions, condition)
+}
+
+// clusterOperatorMapper enqueues the ManilaDriver when its ClusterOperator changes
+type clusterOperatorMapper struct{}
+
+var _ handler.Mapper = &clusterOperatorMapper{}
+
+// Map implements handler.Mapper
+func (m *clusterOperatorMapper) Map(obj handler.MapObject) []reconcile.Request {
+	if obj.Meta.GetName() != clusterOperatorName {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: manilaDriverCRName}},
+	}
+}
+
+// driverWorkloadMapper enqueues the ManilaDriver when the driver Deployment or DaemonSets change,
+// so the ClusterOperator conditions and the workload metrics follow the state of the driver pods
+type driverWorkloadMapper struct{}
+
+var _ handler.Mapper = &driverWorkloadMapper{}
+
+// Map implements handler.Mapper
+func (m *driverWorkloadMapper) Map(obj handler.MapObject) []reconcile.Request {
+	if obj.Meta.GetNamespace() != secretNamespace {
+		return nil
+	}
+
+	name := obj.Meta.GetName()
+	if name != controllerPluginDeploymentName && !isNodePluginDaemonSet(name) {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: manilaDriverCRName}},
+	}
+}
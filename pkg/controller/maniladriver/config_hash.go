@@ -0,0 +1,87 @@
+package maniladriver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	secretHashAnnotation   = "manila.csi.openshift.io/secret-hash"
+	caBundleHashAnnotation = "manila.csi.openshift.io/ca-bundle-hash"
+)
+
+// getConfigHashAnnotations returns pod template annotations with hashes of the driver secret and CA bundle,
+// so any change of their content triggers a rolling restart of the driver pods
+func (r *ReconcileManilaDriver) getConfigHashAnnotations() (map[string]string, error) {
+	secret := &corev1.Secret{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: driverSecretName, Namespace: secretNamespace}, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	cm := &corev1.ConfigMap{}
+	err = r.apiReader.Get(context.TODO(), types.NamespacedName{Name: caCertConfigMapName, Namespace: secretNamespace}, cm)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	caData := make(map[string][]byte, len(cm.Data))
+	for key, value := range cm.Data {
+		caData[key] = []byte(value)
+	}
+
+	return map[string]string{
+		secretHashAnnotation:   hashData(secret.Data),
+		caBundleHashAnnotation: hashData(caData),
+	}, nil
+}
+
+// isConfigRotated reports whether the driver configuration has changed since the controller plugin
+// Deployment was last updated
+func (r *ReconcileManilaDriver) isConfigRotated(annotations map[string]string) (bool, error) {
+	deployment := &appsv1.Deployment{}
+
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: controllerPluginDeploymentName, Namespace: secretNamespace}, deployment)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// The driver has not been deployed yet
+			return false, nil
+		}
+		return false, err
+	}
+
+	current := deployment.Spec.Template.Annotations
+	for name, hash := range annotations {
+		if current[name] != "" && current[name] != hash {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// hashData returns a stable hash of the given data
+func hashData(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(data[key])
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
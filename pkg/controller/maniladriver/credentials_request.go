@@ -8,6 +8,7 @@ import (
 	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -59,6 +60,21 @@ func (r *ReconcileManilaDriver) handleCredentialsRequest(instance *maniladriverv
 	return nil
 }
 
+// releaseCredentialsRequest deletes the Credentials Request when the credentials are taken from another source,
+// so the Cloud Credential Operator removes the credentials it minted for the driver
+func (r *ReconcileManilaDriver) releaseCredentialsRequest(reqLogger logr.Logger) error {
+	cr := generateCredentialsRequest()
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, &credsv1.CredentialsRequest{})
+	if err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+
+	return r.deleteCredentialsRequest(reqLogger)
+}
+
 func (r *ReconcileManilaDriver) deleteCredentialsRequest(reqLogger logr.Logger) error {
 	reqLogger.Info("Deleting Credentials Request")
 
@@ -0,0 +1,120 @@
+package maniladriver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gophercloud/utils/openstack/clientconfig"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// getCredentialsSource returns the configured source of OpenStack credentials
+func getCredentialsSource(instance *maniladriverv1alpha1.ManilaDriver) maniladriverv1alpha1.CredentialsSourceType {
+	if instance.Spec.Credentials == nil || instance.Spec.Credentials.Source == "" {
+		return maniladriverv1alpha1.CredentialsSourceCloudCredentialOperator
+	}
+	return instance.Spec.Credentials.Source
+}
+
+// validateCredentialsSource checks that the credentials source is known and references a secret when required
+func validateCredentialsSource(instance *maniladriverv1alpha1.ManilaDriver) error {
+	source := getCredentialsSource(instance)
+	switch source {
+	case maniladriverv1alpha1.CredentialsSourceCloudCredentialOperator:
+		return nil
+	case maniladriverv1alpha1.CredentialsSourceCloudsYAML, maniladriverv1alpha1.CredentialsSourceDriverSecret:
+		secretRef := instance.Spec.Credentials.SecretRef
+		if secretRef == nil || secretRef.Name == "" || secretRef.Namespace == "" {
+			return fmt.Errorf("credentials source %q requires secretRef with name and namespace", source)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown credentials source %q", source)
+}
+
+// getCredentialsSecretName returns the name of the secret the credentials are read from
+func getCredentialsSecretName(instance *maniladriverv1alpha1.ManilaDriver) types.NamespacedName {
+	if getCredentialsSource(instance) == maniladriverv1alpha1.CredentialsSourceCloudCredentialOperator {
+		return types.NamespacedName{Name: installerSecretName, Namespace: secretNamespace}
+	}
+
+	secretRef := instance.Spec.Credentials.SecretRef
+	return types.NamespacedName{Name: secretRef.Name, Namespace: secretRef.Namespace}
+}
+
+// getCloud returns the cloud from the credentials source configured in the ManilaDriver spec
+func (r *ReconcileManilaDriver) getCloud(instance *maniladriverv1alpha1.ManilaDriver) (clientconfig.Cloud, error) {
+	secretName := getCredentialsSecretName(instance)
+
+	if getCredentialsSource(instance) == maniladriverv1alpha1.CredentialsSourceDriverSecret {
+		return r.getCloudFromDriverSecret(secretName)
+	}
+
+	return r.getCloudFromSecret(secretName, getCloudName(instance))
+}
+
+// getCloudFromDriverSecret converts a secret with the driver os-* keys into a cloud
+func (r *ReconcileManilaDriver) getCloudFromDriverSecret(secretName types.NamespacedName) (clientconfig.Cloud, error) {
+	secret := &corev1.Secret{}
+	err := r.apiReader.Get(context.TODO(), secretName, secret)
+	if err != nil {
+		return clientconfig.Cloud{}, err
+	}
+
+	data := func(key string) string {
+		return string(secret.Data[key])
+	}
+
+	return clientconfig.Cloud{
+		RegionName: data("os-region"),
+		AuthInfo: &clientconfig.AuthInfo{
+			AuthURL:                     data("os-authURL"),
+			UserID:                      data("os-userID"),
+			Username:                    data("os-userName"),
+			Password:                    data("os-password"),
+			ProjectID:                   data("os-projectID"),
+			ProjectName:                 data("os-projectName"),
+			DomainID:                    data("os-domainID"),
+			DomainName:                  data("os-domainName"),
+			ProjectDomainID:             data("os-projectDomainID"),
+			ProjectDomainName:           data("os-projectDomainName"),
+			UserDomainID:                data("os-userDomainID"),
+			UserDomainName:              data("os-userDomainName"),
+			ApplicationCredentialID:     data("os-applicationCredentialID"),
+			ApplicationCredentialName:   data("os-applicationCredentialName"),
+			ApplicationCredentialSecret: data("os-applicationCredentialSecret"),
+		},
+	}, nil
+}
+
+// credentialsSecretMapper enqueues the ManilaDriver when the secret with its credentials changes.
+// The secret is not owned by the operator, so it can't be watched as the other objects.
+type credentialsSecretMapper struct {
+	client client.Client
+}
+
+var _ handler.Mapper = &credentialsSecretMapper{}
+
+// Map implements handler.Mapper
+func (m *credentialsSecretMapper) Map(obj handler.MapObject) []reconcile.Request {
+	instance := &maniladriverv1alpha1.ManilaDriver{}
+	err := m.client.Get(context.TODO(), types.NamespacedName{Name: manilaDriverCRName}, instance)
+	if err != nil || validateCredentialsSource(instance) != nil {
+		return nil
+	}
+
+	secretName := getCredentialsSecretName(instance)
+	if obj.Meta.GetName() != secretName.Name || obj.Meta.GetNamespace() != secretName.Namespace {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: instance.Name}},
+	}
+}
@@ -0,0 +1,52 @@
+package maniladriver
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Ports of the livenessprobe sidecars. The Manila node plugin uses host network, so its port
+// must not conflict with other services on the node.
+const (
+	controllerPluginHealthPort = 9808
+	manilaNodePluginHealthPort = 9809
+	nfsNodePluginHealthPort    = 9810
+)
+
+// generateLivenessProbeContainer returns the livenessprobe sidecar checking the CSI socket at csiAddress
+func generateLivenessProbeContainer(csiAddress string, port int32, socketDirMount corev1.VolumeMount) corev1.Container {
+	return corev1.Container{
+		Name:  "liveness-probe",
+		Image: getCSILivenessProbeImage(),
+		Args: []string{
+			"--v=5",
+			"--csi-address=" + csiAddress,
+			"--probe-timeout=3s",
+			"--health-port=" + strconv.Itoa(int(port)),
+		},
+		ImagePullPolicy: "IfNotPresent",
+		VolumeMounts: []corev1.VolumeMount{
+			socketDirMount,
+		},
+	}
+}
+
+// withLivenessProbe makes the CSI plugin container restarted when the livenessprobe sidecar reports it unhealthy.
+// The port is probed by number and not declared as a container port, because container ports of host network
+// pods become host ports, which are not allowed by the SecurityContextConstraints of the node plugins.
+func withLivenessProbe(container *corev1.Container, port int32) {
+	container.LivenessProbe = &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: "/healthz",
+				Port: intstr.FromInt(int(port)),
+			},
+		},
+		InitialDelaySeconds: 10,
+		TimeoutSeconds:      3,
+		PeriodSeconds:       10,
+		FailureThreshold:    5,
+	}
+}
@@ -0,0 +1,108 @@
+package maniladriver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/sharedfilesystems/apiversions"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+)
+
+// Manila API microversions that introduced the features used by the driver
+const (
+	snapshotsMicroversion               = "2.2"
+	extendShrinkMicroversion            = "2.7"
+	createShareFromSnapshotMicroversion = "2.24"
+	revertToSnapshotMicroversion        = "2.27"
+	accessMetadataMicroversion          = "2.45"
+
+	// maxManilaMicroversion is the highest microversion known to the operator
+	maxManilaMicroversion = accessMetadataMicroversion
+)
+
+// microversion is a parsed Manila API microversion
+type microversion struct {
+	major int
+	minor int
+}
+
+func parseMicroversion(version string) (microversion, error) {
+	parts := strings.Split(version, ".")
+	if len(parts) != 2 {
+		return microversion{}, fmt.Errorf("invalid microversion %q", version)
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return microversion{}, fmt.Errorf("invalid microversion %q: %v", version, err)
+	}
+
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return microversion{}, fmt.Errorf("invalid microversion %q: %v", version, err)
+	}
+
+	return microversion{major: major, minor: minor}, nil
+}
+
+func (v microversion) atLeast(other microversion) bool {
+	if v.major != other.major {
+		return v.major > other.major
+	}
+	return v.minor >= other.minor
+}
+
+func (v microversion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// mustParseMicroversion parses microversions defined in the operator code
+func mustParseMicroversion(version string) microversion {
+	v, err := parseMicroversion(version)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// discoverManilaCapabilities negotiates the highest microversion supported by both Manila and the operator
+// and returns the features available with it
+func discoverManilaCapabilities(version *apiversions.APIVersion) (*maniladriverv1alpha1.ManilaCapabilities, error) {
+	// Manila without microversions only provides the base API
+	negotiated := microversion{major: 2, minor: 0}
+
+	if version.Version != "" {
+		maxVersion, err := parseMicroversion(version.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the maximum Manila microversion: %v", err)
+		}
+
+		negotiated = mustParseMicroversion(maxManilaMicroversion)
+		if !maxVersion.atLeast(negotiated) {
+			negotiated = maxVersion
+		}
+	}
+
+	supports := func(version string) bool {
+		return negotiated.atLeast(mustParseMicroversion(version))
+	}
+
+	return &maniladriverv1alpha1.ManilaCapabilities{
+		Microversion:            negotiated.String(),
+		Snapshots:               supports(snapshotsMicroversion),
+		CreateShareFromSnapshot: supports(createShareFromSnapshotMicroversion),
+		RevertToSnapshot:        supports(revertToSnapshotMicroversion),
+		ExtendShare:             supports(extendShrinkMicroversion),
+		ShrinkShare:             supports(extendShrinkMicroversion),
+		AccessMetadata:          supports(accessMetadataMicroversion),
+	}, nil
+}
+
+// getCapabilities returns the Manila capabilities detected during the last preflight checks
+func getCapabilities(instance *maniladriverv1alpha1.ManilaDriver) maniladriverv1alpha1.ManilaCapabilities {
+	if instance.Status.Capabilities == nil {
+		return maniladriverv1alpha1.ManilaCapabilities{}
+	}
+	return *instance.Status.Capabilities
+}
@@ -12,11 +12,23 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
-func (r *ReconcileManilaDriver) handleManilaControllerPluginDeployment(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) error {
+const (
+	controllerPluginDeploymentName = "openstack-manila-csi-controllerplugin"
+
+	// defaultControllerReplicas is the number of controller plugin replicas, which keeps provisioning
+	// available when a node fails
+	defaultControllerReplicas = int32(2)
+
+	// The Manila plugin socket is shared with the sidecars through an emptyDir volume
+	controllerPluginSocketDir   = "/var/lib/csi/sockets/pluginproxy"
+	controllerPluginCSIEndpoint = "unix://" + controllerPluginSocketDir + "/csi.sock"
+)
+
+func (r *ReconcileManilaDriver) handleManilaControllerPluginDeployment(instance *maniladriverv1alpha1.ManilaDriver, podAnnotations map[string]string, proxyEnv []corev1.EnvVar, reqLogger logr.Logger) error {
 	reqLogger.Info("Reconciling Manila Controller Plugin Deployment")
 
 	// Define a new Deployment object
-	ss := generateManilaControllerPluginDeployment()
+	ss := generateManilaControllerPluginDeployment(instance, podAnnotations, proxyEnv)
 
 	if err := annotator.SetLastAppliedAnnotation(ss); err != nil {
 		return err
@@ -58,20 +70,151 @@ func (r *ReconcileManilaDriver) handleManilaControllerPluginDeployment(instance
 	return nil
 }
 
-func generateManilaControllerPluginDeployment() *appsv1.Deployment {
+// getControllerReplicas returns the number of controller plugin replicas
+func getControllerReplicas(instance *maniladriverv1alpha1.ManilaDriver) int32 {
+	if instance.Spec.ControllerReplicas != nil {
+		return *instance.Spec.ControllerReplicas
+	}
+	return defaultControllerReplicas
+}
+
+// isVolumeExpansionEnabled reports whether volumes can be expanded with the resizer sidecar
+func isVolumeExpansionEnabled(instance *maniladriverv1alpha1.ManilaDriver) bool {
+	return instance.Spec.VolumeExpansion && getCapabilities(instance).ExtendShare
+}
+
+func generateManilaControllerPluginDeployment(instance *maniladriverv1alpha1.ManilaDriver, podAnnotations map[string]string, proxyEnv []corev1.EnvVar) *appsv1.Deployment {
 	trueVar := true
-	replicaNumber := int32(1)
-	mountPropagationBidirectional := corev1.MountPropagationBidirectional
-	hostPathDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
+	falseVar := false
+	replicaNumber := getControllerReplicas(instance)
 	hostPathDirectory := corev1.HostPathDirectory
 
-	return &appsv1.Deployment{
+	// None of the containers mount shares, so they run without privileges
+	unprivileged := func() *corev1.SecurityContext {
+		return &corev1.SecurityContext{
+			Privileged:               &falseVar,
+			AllowPrivilegeEscalation: &falseVar,
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{
+					"ALL",
+				},
+			},
+		}
+	}
+
+	socketDirMount := corev1.VolumeMount{
+		Name:      "socket-dir",
+		MountPath: controllerPluginSocketDir,
+	}
+
+	// generateSidecar returns a CSI sidecar connected to the Manila plugin socket
+	generateSidecar := func(name, image string) corev1.Container {
+		return corev1.Container{
+			Name:            name,
+			SecurityContext: unprivileged(),
+			Image:           image,
+			Args: []string{
+				"--v=5",
+				"--csi-address=$(ADDRESS)",
+				"--leader-election",
+			},
+			Env: []corev1.EnvVar{
+				{
+					Name:  "ADDRESS",
+					Value: controllerPluginCSIEndpoint,
+				},
+			},
+			ImagePullPolicy: "IfNotPresent",
+			VolumeMounts: []corev1.VolumeMount{
+				socketDirMount,
+			},
+		}
+	}
+
+	containers := []corev1.Container{
+		generateSidecar("provisioner", getExternalProvisionerImage()),
+	}
+
+	// The snapshotter sidecar is only deployed when Manila supports snapshots
+	if getCapabilities(instance).Snapshots {
+		containers = append(containers, generateSidecar("snapshotter", getExternalSnaphotterImage()))
+	}
+
+	// The resizer sidecar is only deployed when volume expansion is enabled and Manila supports it
+	if isVolumeExpansionEnabled(instance) {
+		containers = append(containers, generateSidecar("resizer", getExternalResizerImage()))
+	}
+
+	manilaPlugin := corev1.Container{
+		Name:            "nodeplugin",
+		SecurityContext: unprivileged(),
+		Image:           getCSIDriverManilaImage(),
+		Args: []string{
+			"--v=5",
+			"--nodeid=$(NODE_ID)",
+			"--endpoint=$(CSI_ENDPOINT)",
+			"--drivername=$(DRIVER_NAME)",
+			"--share-protocol-selector=$(MANILA_SHARE_PROTO)",
+			"--fwdendpoint=$(FWD_CSI_ENDPOINT)",
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "DRIVER_NAME",
+				Value: "manila.csi.openstack.org",
+			},
+			{
+				Name: "NODE_ID",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath: "spec.nodeName",
+					},
+				},
+			},
+			{
+				Name:  "CSI_ENDPOINT",
+				Value: controllerPluginCSIEndpoint,
+			},
+			{
+				Name:  "FWD_CSI_ENDPOINT",
+				Value: "unix:///var/lib/kubelet/plugins/csi-nfsplugin/csi.sock",
+			},
+			{
+				Name:  "MANILA_SHARE_PROTO",
+				Value: "NFS",
+			},
+		},
+		ImagePullPolicy: "IfNotPresent",
+		VolumeMounts: []corev1.VolumeMount{
+			socketDirMount,
+			{
+				// The plugin talks to the NFS node plugin on the same host, which is the only host access it needs
+				Name:      "fwd-plugin-dir",
+				MountPath: "/var/lib/kubelet/plugins/csi-nfsplugin",
+			},
+			{
+				Name:      "openstack-certificates",
+				MountPath: "/usr/share/pki/ca-trust-source",
+			},
+		},
+	}
+
+	// The Manila plugin talks to OpenStack, which may be reachable only through the proxy
+	manilaPlugin.Env = append(manilaPlugin.Env, proxyEnv...)
+
+	// Restart the Manila plugin when its socket stops responding
+	withLivenessProbe(&manilaPlugin, controllerPluginHealthPort)
+	livenessProbe := generateLivenessProbeContainer(controllerPluginSocketDir+"/csi.sock", controllerPluginHealthPort, socketDirMount)
+	livenessProbe.SecurityContext = unprivileged()
+
+	containers = append(containers, manilaPlugin, livenessProbe)
+
+	deployment := &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Deployment",
 			APIVersion: "apps/v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "openstack-manila-csi-controllerplugin",
+			Name:      controllerPluginDeploymentName,
 			Namespace: "openshift-manila-csi-driver",
 			Labels:    labelsManilaControllerPlugin,
 		},
@@ -82,155 +225,33 @@ func generateManilaControllerPluginDeployment() *appsv1.Deployment {
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labelsManilaControllerPlugin,
+					Labels:      labelsManilaControllerPlugin,
+					Annotations: podAnnotations,
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: "openstack-manila-csi-controllerplugin",
-					Containers: []corev1.Container{
-						{
-							Name: "provisioner",
-							SecurityContext: &corev1.SecurityContext{
-								Privileged: &trueVar,
-								Capabilities: &corev1.Capabilities{
-									Add: []corev1.Capability{
-										"SYS_ADMIN",
-									},
-								},
-								AllowPrivilegeEscalation: &trueVar,
-							},
-							Image: getExternalProvisionerImage(),
-							Args: []string{
-								"--v=5",
-								"--csi-address=$(ADDRESS)",
-							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "ADDRESS",
-									Value: "unix:///var/lib/kubelet/plugins/manila.csi.openstack.org/csi-controllerplugin.sock",
-								},
-							},
-							ImagePullPolicy: "IfNotPresent",
-							VolumeMounts: []corev1.VolumeMount{
+					Affinity: &corev1.Affinity{
+						// Spread the replicas across nodes, so a node failure doesn't stop provisioning
+						PodAntiAffinity: &corev1.PodAntiAffinity{
+							PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
 								{
-									Name:      "plugin-dir",
-									MountPath: "/var/lib/kubelet/plugins/manila.csi.openstack.org",
-								},
-								{
-									Name:      "openstack-certificates",
-									MountPath: "/usr/share/pki/ca-trust-source",
-								},
-							},
-						},
-						{
-							Name: "snapshotter",
-							SecurityContext: &corev1.SecurityContext{
-								Privileged: &trueVar,
-								Capabilities: &corev1.Capabilities{
-									Add: []corev1.Capability{
-										"SYS_ADMIN",
-									},
-								},
-								AllowPrivilegeEscalation: &trueVar,
-							},
-							Image: getExternalSnaphotterImage(),
-							Args: []string{
-								"--v=5",
-								"--csi-address=$(ADDRESS)",
-							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "ADDRESS",
-									Value: "unix:///var/lib/kubelet/plugins/manila.csi.openstack.org/csi-controllerplugin.sock",
-								},
-							},
-							ImagePullPolicy: "IfNotPresent",
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      "plugin-dir",
-									MountPath: "/var/lib/kubelet/plugins/manila.csi.openstack.org",
-								},
-								{
-									Name:      "openstack-certificates",
-									MountPath: "/usr/share/pki/ca-trust-source",
-								},
-							},
-						},
-						{
-							Name: "nodeplugin",
-							SecurityContext: &corev1.SecurityContext{
-								Privileged: &trueVar,
-								Capabilities: &corev1.Capabilities{
-									Add: []corev1.Capability{
-										"SYS_ADMIN",
-									},
-								},
-								AllowPrivilegeEscalation: &trueVar,
-							},
-							Image: getCSIDriverManilaImage(),
-							Args: []string{
-								"--v=5",
-								"--nodeid=$(NODE_ID)",
-								"--endpoint=$(CSI_ENDPOINT)",
-								"--drivername=$(DRIVER_NAME)",
-								"--share-protocol-selector=$(MANILA_SHARE_PROTO)",
-								"--fwdendpoint=$(FWD_CSI_ENDPOINT)",
-							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "DRIVER_NAME",
-									Value: "manila.csi.openstack.org",
-								},
-								{
-									Name: "NODE_ID",
-									ValueFrom: &corev1.EnvVarSource{
-										FieldRef: &corev1.ObjectFieldSelector{
-											FieldPath: "spec.nodeName",
+									Weight: 100,
+									PodAffinityTerm: corev1.PodAffinityTerm{
+										LabelSelector: &metav1.LabelSelector{
+											MatchLabels: labelsManilaControllerPlugin,
 										},
+										TopologyKey: "kubernetes.io/hostname",
 									},
 								},
-								{
-									Name:  "CSI_ENDPOINT",
-									Value: "unix:///var/lib/kubelet/plugins/manila.csi.openstack.org/csi-controllerplugin.sock",
-								},
-								{
-									Name:  "FWD_CSI_ENDPOINT",
-									Value: "unix:///var/lib/kubelet/plugins/csi-nfsplugin/csi.sock",
-								},
-								{
-									Name:  "MANILA_SHARE_PROTO",
-									Value: "NFS",
-								},
-							},
-							ImagePullPolicy: "IfNotPresent",
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      "plugin-dir",
-									MountPath: "/var/lib/kubelet/plugins/manila.csi.openstack.org",
-								},
-								{
-									Name:      "fwd-plugin-dir",
-									MountPath: "/var/lib/kubelet/plugins/csi-nfsplugin",
-								},
-								{
-									Name:             "pod-mounts",
-									MountPath:        "/var/lib/kubelet/pods",
-									MountPropagation: &mountPropagationBidirectional,
-								},
-								{
-									Name:      "openstack-certificates",
-									MountPath: "/usr/share/pki/ca-trust-source",
-								},
 							},
 						},
 					},
+					Containers: containers,
 					Volumes: []corev1.Volume{
 						{
-							Name: "plugin-dir",
+							Name: "socket-dir",
 							VolumeSource: corev1.VolumeSource{
-								HostPath: &corev1.HostPathVolumeSource{
-									Path: "/var/lib/kubelet/plugins/manila.csi.openstack.org",
-									Type: &hostPathDirectoryOrCreate,
-								},
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
 							},
 						},
 						{
@@ -242,15 +263,6 @@ func generateManilaControllerPluginDeployment() *appsv1.Deployment {
 								},
 							},
 						},
-						{
-							Name: "pod-mounts",
-							VolumeSource: corev1.VolumeSource{
-								HostPath: &corev1.HostPathVolumeSource{
-									Path: "/var/lib/kubelet/pods",
-									Type: &hostPathDirectory,
-								},
-							},
-						},
 						{
 							Name: "openstack-certificates",
 							VolumeSource: corev1.VolumeSource{
@@ -267,4 +279,13 @@ func generateManilaControllerPluginDeployment() *appsv1.Deployment {
 			},
 		},
 	}
+
+	// Expose metrics of the sidecars to the cluster monitoring
+	withSidecarMetrics(&deployment.Spec.Template.Spec, controllerPluginSidecarMetrics, controllerPluginMetricsCertSecretName, unprivileged())
+
+	if isTopologyEnabled(instance) {
+		withTopologyArgs(&deployment.Spec.Template.Spec)
+	}
+
+	return deployment
 }
@@ -0,0 +1,118 @@
+package maniladriver
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var (
+	// podDisruptionBudgetGVK is the PodDisruptionBudget API served by Kubernetes 1.21 and newer
+	podDisruptionBudgetGVK = schema.GroupVersionKind{
+		Group:   "policy",
+		Version: "v1",
+		Kind:    "PodDisruptionBudget",
+	}
+
+	// podDisruptionBudgetV1beta1GVK is the PodDisruptionBudget API used on older clusters, it's removed in Kubernetes 1.25
+	podDisruptionBudgetV1beta1GVK = schema.GroupVersionKind{
+		Group:   "policy",
+		Version: "v1beta1",
+		Kind:    "PodDisruptionBudget",
+	}
+)
+
+// getPodDisruptionBudgetGVK discovers the PodDisruptionBudget API version served by the cluster, preferring v1
+func getPodDisruptionBudgetGVK(mapper meta.RESTMapper) schema.GroupVersionKind {
+	if _, err := mapper.RESTMapping(podDisruptionBudgetGVK.GroupKind(), podDisruptionBudgetGVK.Version); err == nil {
+		return podDisruptionBudgetGVK
+	}
+	return podDisruptionBudgetV1beta1GVK
+}
+
+func (r *ReconcileManilaDriver) handleManilaControllerPluginPodDisruptionBudget(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) error {
+	reqLogger.Info("Reconciling Manila Controller Plugin PodDisruptionBudget")
+
+	// Define a new PodDisruptionBudget object
+	pdb := generateManilaControllerPluginPodDisruptionBudget(getPodDisruptionBudgetGVK(r.restMapper))
+
+	// Check if this PodDisruptionBudget already exists
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(pdb.GroupVersionKind())
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: pdb.GetName(), Namespace: pdb.GetNamespace()}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if getControllerReplicas(instance) < 2 {
+		// A single replica can't be protected from disruptions without blocking node drains
+		if err == nil {
+			reqLogger.Info("Deleting PodDisruptionBudget", "PodDisruptionBudget.Namespace", found.GetNamespace(), "PodDisruptionBudget.Name", found.GetName())
+			return r.client.Delete(context.TODO(), found)
+		}
+		return nil
+	}
+
+	if err := annotator.SetLastAppliedAnnotation(pdb); err != nil {
+		return err
+	}
+
+	if errors.IsNotFound(err) {
+		reqLogger.Info("Creating a new PodDisruptionBudget", "PodDisruptionBudget.Namespace", pdb.GetNamespace(), "PodDisruptionBudget.Name", pdb.GetName(), "PodDisruptionBudget.APIVersion", pdb.GetAPIVersion())
+		return r.client.Create(context.TODO(), pdb)
+	}
+
+	// Check if we need to update the object. PodDisruptionBudgets created through v1beta1 have the v1beta1
+	// object in their last applied annotation, so they are recreated through v1 here too.
+	equal, err := compareLastAppliedAnnotations(found, pdb)
+	if err != nil {
+		return err
+	}
+
+	if !equal {
+		// PodDisruptionBudget spec can't be updated, so we have to delete it and create again
+		reqLogger.Info("Deleting PodDisruptionBudget", "PodDisruptionBudget.Namespace", found.GetNamespace(), "PodDisruptionBudget.Name", found.GetName())
+		err = r.client.Delete(context.TODO(), found)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+
+		reqLogger.Info("Creating a new PodDisruptionBudget", "PodDisruptionBudget.Namespace", pdb.GetNamespace(), "PodDisruptionBudget.Name", pdb.GetName(), "PodDisruptionBudget.APIVersion", pdb.GetAPIVersion())
+		return r.client.Create(context.TODO(), pdb)
+	}
+
+	// PodDisruptionBudget already exists - don't requeue
+	reqLogger.Info("Skip reconcile: PodDisruptionBudget already exists", "PodDisruptionBudget.Namespace", found.GetNamespace(), "PodDisruptionBudget.Name", found.GetName())
+
+	return nil
+}
+
+func generateManilaControllerPluginPodDisruptionBudget(gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	matchLabels := map[string]interface{}{}
+	for k, v := range labelsManilaControllerPlugin {
+		matchLabels[k] = v
+	}
+
+	pdb := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"minAvailable": int64(1),
+				"selector": map[string]interface{}{
+					"matchLabels": matchLabels,
+				},
+			},
+		},
+	}
+	pdb.SetGroupVersionKind(gvk)
+	pdb.SetName(controllerPluginDeploymentName)
+	pdb.SetNamespace(secretNamespace)
+	pdb.SetLabels(labelsManilaControllerPlugin)
+
+	return pdb
+}
@@ -220,6 +220,11 @@ func (r *ReconcileManilaDriver) handleManilaControllerPluginRole(instance *manil
 				Resources: []string{"configmaps"},
 				Verbs:     []string{"get", "list", "watch", "create", "delete"},
 			},
+			{
+				APIGroups: []string{"coordination.k8s.io"},
+				Resources: []string{"leases"},
+				Verbs:     []string{"get", "watch", "list", "delete", "update", "create"},
+			},
 		},
 	}
 
@@ -373,13 +378,23 @@ func generateManilaControllerPluginClusterRole() *rbacv1.ClusterRole {
 			{
 				APIGroups: []string{""},
 				Resources: []string{"persistentvolumes"},
-				Verbs:     []string{"get", "list", "watch", "create", "delete"},
+				Verbs:     []string{"get", "list", "watch", "create", "delete", "patch"},
 			},
 			{
 				APIGroups: []string{""},
 				Resources: []string{"persistentvolumeclaims"},
 				Verbs:     []string{"get", "list", "watch", "update"},
 			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"persistentvolumeclaims/status"},
+				Verbs:     []string{"update", "patch"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
 			{
 				APIGroups: []string{""},
 				Resources: []string{"events"},
@@ -425,6 +440,17 @@ func generateManilaControllerPluginClusterRole() *rbacv1.ClusterRole {
 				Resources: []string{"customresourcedefinitions"},
 				Verbs:     []string{"create", "list", "watch", "delete", "get", "update"},
 			},
+			{
+				// kube-rbac-proxy authorizes the clients of the sidecar metrics
+				APIGroups: []string{"authentication.k8s.io"},
+				Resources: []string{"tokenreviews"},
+				Verbs:     []string{"create"},
+			},
+			{
+				APIGroups: []string{"authorization.k8s.io"},
+				Resources: []string{"subjectaccessreviews"},
+				Verbs:     []string{"create"},
+			},
 		},
 	}
 }
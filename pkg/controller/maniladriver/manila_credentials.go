@@ -2,6 +2,7 @@ package maniladriver
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/go-logr/logr"
 	"github.com/gophercloud/utils/openstack/clientconfig"
@@ -17,20 +18,110 @@ const (
 	installerSecretName = "installer-cloud-credentials"
 	driverSecretName    = "csi-manila-secrets"
 	secretNamespace     = "openshift-manila-csi-driver"
-	cloudName           = "openstack"
+	defaultCloudName    = "openstack"
 )
 
+// supportedAuthTypes contains Keystone authentication methods that can be passed to the driver
+var supportedAuthTypes = map[clientconfig.AuthType]bool{
+	"":                                       true,
+	clientconfig.AuthPassword:                true,
+	clientconfig.AuthV3Password:              true,
+	clientconfig.AuthV3ApplicationCredential: true,
+}
+
+// validateAuthType checks that the cloud uses an authentication method supported by the driver
+func validateAuthType(cloud clientconfig.Cloud) error {
+	if !supportedAuthTypes[cloud.AuthType] {
+		return fmt.Errorf("OpenStack auth type %q is not supported, use one of %q, %q or %q", cloud.AuthType, clientconfig.AuthPassword, clientconfig.AuthV3Password, clientconfig.AuthV3ApplicationCredential)
+	}
+
+	return nil
+}
+
+// validateCloud checks that the cloud contains everything required to authenticate in Keystone
+func validateCloud(name string, cloud clientconfig.Cloud) error {
+	authInfo := cloud.AuthInfo
+	if authInfo == nil {
+		return fmt.Errorf("cloud %q does not contain the auth section", name)
+	}
+
+	if authInfo.AuthURL == "" {
+		return fmt.Errorf("cloud %q does not contain auth_url", name)
+	}
+
+	if cloud.AuthType == clientconfig.AuthV3ApplicationCredential && !isApplicationCredential(authInfo) {
+		return fmt.Errorf("cloud %q uses auth type %q, but contains neither application_credential_id nor application_credential_name", name, cloud.AuthType)
+	}
+
+	if isApplicationCredential(authInfo) {
+		if authInfo.ApplicationCredentialSecret == "" {
+			return fmt.Errorf("cloud %q does not contain application_credential_secret", name)
+		}
+
+		// Application credentials referenced by name belong to a user, who has to be identified too
+		if authInfo.ApplicationCredentialID == "" {
+			if authInfo.UserID == "" && authInfo.Username == "" {
+				return fmt.Errorf("cloud %q references application credential %q by name, but contains neither user_id nor username", name, authInfo.ApplicationCredentialName)
+			}
+			if authInfo.UserID == "" && !hasUserDomain(authInfo) {
+				return fmt.Errorf("cloud %q references application credential %q by name, but does not contain the user domain", name, authInfo.ApplicationCredentialName)
+			}
+		}
+
+		return nil
+	}
+
+	if authInfo.UserID == "" && authInfo.Username == "" {
+		return fmt.Errorf("cloud %q contains neither user_id nor username", name)
+	}
+	if authInfo.UserID == "" && !hasUserDomain(authInfo) {
+		return fmt.Errorf("cloud %q identifies the user by name, but does not contain the user domain", name)
+	}
+	if authInfo.Password == "" {
+		return fmt.Errorf("cloud %q does not contain password", name)
+	}
+	if authInfo.ProjectID == "" && authInfo.ProjectName == "" {
+		return fmt.Errorf("cloud %q contains neither project_id nor project_name", name)
+	}
+	if authInfo.ProjectID == "" && !hasProjectDomain(authInfo) {
+		return fmt.Errorf("cloud %q identifies the project by name, but does not contain the project domain", name)
+	}
+
+	return nil
+}
+
+func hasUserDomain(authInfo *clientconfig.AuthInfo) bool {
+	return authInfo.UserDomainID != "" || authInfo.UserDomainName != "" || authInfo.DomainID != "" || authInfo.DomainName != ""
+}
+
+func hasProjectDomain(authInfo *clientconfig.AuthInfo) bool {
+	return authInfo.ProjectDomainID != "" || authInfo.ProjectDomainName != "" || authInfo.DomainID != "" || authInfo.DomainName != ""
+}
+
+// getCloudName returns the name of the clouds.yaml entry used by the driver
+func getCloudName(instance *maniladriverv1alpha1.ManilaDriver) string {
+	if instance.Spec.CloudName != "" {
+		return instance.Spec.CloudName
+	}
+	return defaultCloudName
+}
+
+// isApplicationCredential determines if an application credential is used to authenticate
+func isApplicationCredential(authInfo *clientconfig.AuthInfo) bool {
+	return authInfo.ApplicationCredentialID != "" || authInfo.ApplicationCredentialName != ""
+}
+
 // createDriverCredentialsSecret converts the installer secret, if it is available, into the driver secret
 func (r *ReconcileManilaDriver) createDriverCredentialsSecret(instance *maniladriverv1alpha1.ManilaDriver, cloudConfig clientconfig.Cloud, reqLogger logr.Logger) error {
 	reqLogger.Info("Reconciling Manila Credentials")
 
-	cert, err := r.getCloudProviderCert()
+	cert, err := r.getCABundle()
 	if err != nil {
 		return err
 	}
 
 	if cert != "" {
-		cloudConfig.CACertFile = "/usr/share/pki/ca-trust-source/cloud-provider-ca-bundle.pem"
+		cloudConfig.CACertFile = "/usr/share/pki/ca-trust-source/" + caCertKey
 	}
 
 	secret := generateSecret(cloudConfig)
@@ -86,28 +177,44 @@ func generateSecret(cloud clientconfig.Cloud) *corev1.Secret {
 	if cloud.RegionName != "" {
 		data["os-region"] = []byte(cloud.RegionName)
 	}
-	if cloud.AuthInfo.UserID != "" {
-		data["os-userID"] = []byte(cloud.AuthInfo.UserID)
-	} else if cloud.AuthInfo.Username != "" {
-		data["os-userName"] = []byte(cloud.AuthInfo.Username)
-	}
-	if cloud.AuthInfo.Password != "" {
-		data["os-password"] = []byte(cloud.AuthInfo.Password)
-	}
-	if cloud.AuthInfo.ProjectID != "" {
-		data["os-projectID"] = []byte(cloud.AuthInfo.ProjectID)
-	} else if cloud.AuthInfo.ProjectName != "" {
-		data["os-projectName"] = []byte(cloud.AuthInfo.ProjectName)
-	}
-	if cloud.AuthInfo.DomainID != "" {
-		data["os-domainID"] = []byte(cloud.AuthInfo.DomainID)
-	} else if cloud.AuthInfo.DomainName != "" {
-		data["os-domainName"] = []byte(cloud.AuthInfo.DomainName)
-	}
-	if cloud.AuthInfo.ProjectDomainID != "" {
-		data["os-projectDomainID"] = []byte(cloud.AuthInfo.ProjectDomainID)
-	} else if cloud.AuthInfo.ProjectDomainName != "" {
-		data["os-projectDomainName"] = []byte(cloud.AuthInfo.ProjectDomainName)
+	if isApplicationCredential(cloud.AuthInfo) {
+		// Application credentials are already scoped to a project, so only the credential
+		// itself and, when it is referenced by name, its owner are passed to the driver
+		if cloud.AuthInfo.ApplicationCredentialID != "" {
+			data["os-applicationCredentialID"] = []byte(cloud.AuthInfo.ApplicationCredentialID)
+		} else {
+			data["os-applicationCredentialName"] = []byte(cloud.AuthInfo.ApplicationCredentialName)
+			if cloud.AuthInfo.UserID != "" {
+				data["os-userID"] = []byte(cloud.AuthInfo.UserID)
+			} else if cloud.AuthInfo.Username != "" {
+				data["os-userName"] = []byte(cloud.AuthInfo.Username)
+			}
+		}
+		data["os-applicationCredentialSecret"] = []byte(cloud.AuthInfo.ApplicationCredentialSecret)
+	} else {
+		if cloud.AuthInfo.UserID != "" {
+			data["os-userID"] = []byte(cloud.AuthInfo.UserID)
+		} else if cloud.AuthInfo.Username != "" {
+			data["os-userName"] = []byte(cloud.AuthInfo.Username)
+		}
+		if cloud.AuthInfo.Password != "" {
+			data["os-password"] = []byte(cloud.AuthInfo.Password)
+		}
+		if cloud.AuthInfo.ProjectID != "" {
+			data["os-projectID"] = []byte(cloud.AuthInfo.ProjectID)
+		} else if cloud.AuthInfo.ProjectName != "" {
+			data["os-projectName"] = []byte(cloud.AuthInfo.ProjectName)
+		}
+		if cloud.AuthInfo.DomainID != "" {
+			data["os-domainID"] = []byte(cloud.AuthInfo.DomainID)
+		} else if cloud.AuthInfo.DomainName != "" {
+			data["os-domainName"] = []byte(cloud.AuthInfo.DomainName)
+		}
+		if cloud.AuthInfo.ProjectDomainID != "" {
+			data["os-projectDomainID"] = []byte(cloud.AuthInfo.ProjectDomainID)
+		} else if cloud.AuthInfo.ProjectDomainName != "" {
+			data["os-projectDomainName"] = []byte(cloud.AuthInfo.ProjectDomainName)
+		}
 	}
 	if cloud.AuthInfo.UserDomainID != "" {
 		data["os-userDomainID"] = []byte(cloud.AuthInfo.UserDomainID)
@@ -5,33 +5,53 @@ import (
 
 	"github.com/go-logr/logr"
 	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
-	storagev1beta1 "k8s.io/api/storage/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 )
 
-// falsePTR returns a *bool whose underlying value is false.
-func falsePTR() *bool {
-	t := false
-	return &t
+var (
+	// csiDriverGVK is the CSIDriver API served by Kubernetes 1.18 and newer
+	csiDriverGVK = schema.GroupVersionKind{
+		Group:   "storage.k8s.io",
+		Version: "v1",
+		Kind:    "CSIDriver",
+	}
+
+	// csiDriverV1beta1GVK is the CSIDriver API used on older clusters, it's removed in Kubernetes 1.22
+	csiDriverV1beta1GVK = schema.GroupVersionKind{
+		Group:   "storage.k8s.io",
+		Version: "v1beta1",
+		Kind:    "CSIDriver",
+	}
+)
+
+// getCSIDriverGVK discovers the CSIDriver API version served by the cluster, preferring v1
+func getCSIDriverGVK(mapper meta.RESTMapper) schema.GroupVersionKind {
+	if _, err := mapper.RESTMapping(csiDriverGVK.GroupKind(), csiDriverGVK.Version); err == nil {
+		return csiDriverGVK
+	}
+	return csiDriverV1beta1GVK
 }
 
 func (r *ReconcileManilaDriver) handleManilaCSIDriver(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) error {
 	reqLogger.Info("Reconciling Manila CSIDriver")
 
 	// Define a new CSIDriver object
-	driver := generateCSIDriver()
+	driver := generateCSIDriver(instance, getCSIDriverGVK(r.restMapper))
 
 	if err := annotator.SetLastAppliedAnnotation(driver); err != nil {
 		return err
 	}
 
 	// Check if this CSIDriver already exists
-	found := &storagev1beta1.CSIDriver{}
-	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: driver.Name, Namespace: ""}, found)
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(driver.GroupVersionKind())
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: driver.GetName(), Namespace: ""}, found)
 	if err != nil && errors.IsNotFound(err) {
-		reqLogger.Info("Creating a new CSIDriver", "CSIDriver.Name", driver.Name)
+		reqLogger.Info("Creating a new CSIDriver", "CSIDriver.Name", driver.GetName(), "CSIDriver.APIVersion", driver.GetAPIVersion())
 		err = r.client.Create(context.TODO(), driver)
 		if err != nil {
 			return err
@@ -43,49 +63,87 @@ func (r *ReconcileManilaDriver) handleManilaCSIDriver(instance *maniladriverv1al
 		return err
 	}
 
-	// Check if we need to update the object
+	// Check if we need to update the object. CSIDrivers created through v1beta1 have the v1beta1
+	// object in their last applied annotation, so they are migrated here too.
 	equal, err := compareLastAppliedAnnotations(found, driver)
 	if err != nil {
 		return err
 	}
 
-	if !equal {
-		reqLogger.Info("Updating CSIDriver with new changes", "CSIDriver.Namespace", found.Namespace, "CSIDriver.Name", found.Name)
-		err = r.client.Update(context.TODO(), driver)
-		if err != nil {
-			return err
-		}
-	} else {
+	if equal {
 		// CSIDriver already exists - don't requeue
-		reqLogger.Info("Skip reconcile: CSIDriver already exists", "CSIDriver.Namespace", found.Namespace, "CSIDriver.Name", found.Name)
+		reqLogger.Info("Skip reconcile: CSIDriver already exists", "CSIDriver.Name", found.GetName())
+		return nil
 	}
 
-	return nil
+	reqLogger.Info("Updating CSIDriver with new changes", "CSIDriver.Name", found.GetName(), "CSIDriver.APIVersion", driver.GetAPIVersion())
+	driver.SetResourceVersion(found.GetResourceVersion())
+	err = r.client.Update(context.TODO(), driver)
+	if err == nil || !errors.IsInvalid(err) {
+		return err
+	}
+
+	// Most of the CSIDriver spec is immutable, so the object is recreated when it changes.
+	// Volumes that are already mounted are not affected.
+	reqLogger.Info("Recreating CSIDriver with immutable changes", "CSIDriver.Name", found.GetName())
+	err = r.client.Delete(context.TODO(), found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	driver.SetResourceVersion("")
+	return r.client.Create(context.TODO(), driver)
 }
 
 func (r *ReconcileManilaDriver) deleteCSIDriver(reqLogger logr.Logger) error {
 	reqLogger.Info("Deleting CSI Driver")
 
-	driver := generateCSIDriver()
+	driver := generateCSIDriver(&maniladriverv1alpha1.ManilaDriver{}, getCSIDriverGVK(r.restMapper))
 
 	err := r.client.Delete(context.TODO(), driver)
 	if err != nil {
 		return err
 	}
 
-	reqLogger.Info("CSI Driver was deleted succesfully", "CSIDriver.Name", driver.Name)
+	reqLogger.Info("CSI Driver was deleted succesfully", "CSIDriver.Name", driver.GetName())
 
 	return nil
 }
 
-func generateCSIDriver() *storagev1beta1.CSIDriver {
-	return &storagev1beta1.CSIDriver{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "manila.csi.openstack.org",
-		},
-		Spec: storagev1beta1.CSIDriverSpec{
-			AttachRequired: falsePTR(),
-			PodInfoOnMount: falsePTR(),
+func getVolumeLifecycleModes(instance *maniladriverv1alpha1.ManilaDriver) []interface{} {
+	modes := []interface{}{string(maniladriverv1alpha1.VolumeLifecyclePersistent)}
+	if instance.Spec.CSIDriver != nil && len(instance.Spec.CSIDriver.VolumeLifecycleModes) > 0 {
+		modes = make([]interface{}, 0, len(instance.Spec.CSIDriver.VolumeLifecycleModes))
+		for _, mode := range instance.Spec.CSIDriver.VolumeLifecycleModes {
+			modes = append(modes, string(mode))
+		}
+	}
+	return modes
+}
+
+func generateCSIDriver(instance *maniladriverv1alpha1.ManilaDriver, gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"attachRequired":       false,
+		"podInfoOnMount":       false,
+		"volumeLifecycleModes": getVolumeLifecycleModes(instance),
+	}
+
+	if instance.Spec.CSIDriver != nil {
+		if instance.Spec.CSIDriver.FSGroupPolicy != "" {
+			spec["fsGroupPolicy"] = string(instance.Spec.CSIDriver.FSGroupPolicy)
+		}
+		if instance.Spec.CSIDriver.RequiresRepublish {
+			spec["requiresRepublish"] = true
+		}
+	}
+
+	driver := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": spec,
 		},
 	}
+	driver.SetGroupVersionKind(gvk)
+	driver.SetName("manila.csi.openstack.org")
+
+	return driver
 }
@@ -32,11 +32,31 @@ func (r *ReconcileManilaDriver) handleManilaDriverNamespace(instance *maniladriv
 		return err
 	}
 
+	updated, err := r.ensureClusterMonitoringLabel(found, reqLogger)
+	if err != nil || updated {
+		return err
+	}
+
 	// Namespace already exists - don't requeue
 	reqLogger.Info("Skip reconcile: Namespace already exists", "Namespace.Name", found.Name)
 	return nil
 }
 
+// ensureClusterMonitoringLabel labels the namespace, so the cluster monitoring scrapes the metrics in it.
+// It returns true when the namespace was updated.
+func (r *ReconcileManilaDriver) ensureClusterMonitoringLabel(ns *corev1.Namespace, reqLogger logr.Logger) (bool, error) {
+	if ns.Labels[clusterMonitoringNamespace] == "true" {
+		return false, nil
+	}
+
+	reqLogger.Info("Updating Namespace with new changes", "Namespace.Name", ns.Name)
+	if ns.Labels == nil {
+		ns.Labels = map[string]string{}
+	}
+	ns.Labels[clusterMonitoringNamespace] = "true"
+	return true, r.client.Update(context.TODO(), ns)
+}
+
 func (r *ReconcileManilaDriver) deleteManilaDriverNamespace(reqLogger logr.Logger) error {
 	reqLogger.Info("Deleting Manila Driver Namespace")
 
@@ -54,6 +74,9 @@ func generateManilaNamespace() *corev1.Namespace {
 	return &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "openshift-manila-csi-driver",
+			Labels: map[string]string{
+				clusterMonitoringNamespace: "true",
+			},
 		},
 	}
 }
@@ -7,15 +7,21 @@ import (
 const (
 	defaultExternalProvisionerImage    = "quay.io/openshift/origin-csi-external-provisioner:latest"
 	defaultExternalSnaphotterImage     = "quay.io/openshift/origin-csi-external-snapshotter:latest"
+	defaultExternalResizerImage        = "quay.io/openshift/origin-csi-external-resizer:latest"
 	defaultCSIDriverManilaImage        = "quay.io/openshift/origin-csi-driver-manila:latest"
 	defaultCSINodeDriverRegistrarImage = "quay.io/openshift/origin-csi-node-driver-registrar:latest"
 	defaultCSIDriverNFSImage           = "quay.io/openshift/origin-csi-driver-nfs:latest"
+	defaultCSILivenessProbeImage       = "quay.io/openshift/origin-csi-livenessprobe:latest"
+	defaultKubeRBACProxyImage          = "quay.io/openshift/origin-kube-rbac-proxy:latest"
 
 	externalProvisionerImageEnv    = "EXTERNAL_PROVISIONER_IMAGE"
 	externalSnaphotterImageEnv     = "EXTERNAL_SNAPSHOTTER_IMAGE"
+	externalResizerImageEnv        = "EXTERNAL_RESIZER_IMAGE"
 	csiDriverManilaImageEnv        = "CSI_DRIVER_MANILA_IMAGE"
 	csiNodeDriverRegistrarImageEnv = "CSI_NODE_DRIVER_REGISTRAR_IMAGE"
 	csiDriverNFSImage              = "CSI_DRIVER_NFS_IMAGE"
+	csiLivenessProbeImageEnv       = "CSI_LIVENESS_PROBE_IMAGE"
+	kubeRBACProxyImageEnv          = "KUBE_RBAC_PROXY_IMAGE"
 )
 
 func getExternalProvisionerImage() string {
@@ -32,6 +38,13 @@ func getExternalSnaphotterImage() string {
 	return defaultExternalSnaphotterImage
 }
 
+func getExternalResizerImage() string {
+	if externalResizerImageFromEnv := os.Getenv(externalResizerImageEnv); externalResizerImageFromEnv != "" {
+		return externalResizerImageFromEnv
+	}
+	return defaultExternalResizerImage
+}
+
 func getCSIDriverManilaImage() string {
 	if csiDriverManilaImageFromEnv := os.Getenv(csiDriverManilaImageEnv); csiDriverManilaImageFromEnv != "" {
 		return csiDriverManilaImageFromEnv
@@ -52,3 +65,17 @@ func getCSIDriverNFSImage() string {
 	}
 	return defaultCSIDriverNFSImage
 }
+
+func getCSILivenessProbeImage() string {
+	if csiLivenessProbeImageFromEnv := os.Getenv(csiLivenessProbeImageEnv); csiLivenessProbeImageFromEnv != "" {
+		return csiLivenessProbeImageFromEnv
+	}
+	return defaultCSILivenessProbeImage
+}
+
+func getKubeRBACProxyImage() string {
+	if kubeRBACProxyImageFromEnv := os.Getenv(kubeRBACProxyImageEnv); kubeRBACProxyImageFromEnv != "" {
+		return kubeRBACProxyImageFromEnv
+	}
+	return defaultKubeRBACProxyImage
+}
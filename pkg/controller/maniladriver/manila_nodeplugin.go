@@ -12,11 +12,11 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
-func (r *ReconcileManilaDriver) handleManilaNodePluginDaemonSet(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) error {
+func (r *ReconcileManilaDriver) handleManilaNodePluginDaemonSet(instance *maniladriverv1alpha1.ManilaDriver, podAnnotations map[string]string, proxyEnv []corev1.EnvVar, reqLogger logr.Logger) error {
 	reqLogger.Info("Reconciling Manila Node Plugin DaemonSet")
 
 	// Define a new DaemonSet object
-	ds := generateManilaNodePluginManifest()
+	ds := generateManilaNodePluginManifest(instance, podAnnotations, proxyEnv)
 
 	if err := annotator.SetLastAppliedAnnotation(ds); err != nil {
 		return err
@@ -58,13 +58,13 @@ func (r *ReconcileManilaDriver) handleManilaNodePluginDaemonSet(instance *manila
 	return nil
 }
 
-func generateManilaNodePluginManifest() *appsv1.DaemonSet {
+func generateManilaNodePluginManifest(instance *maniladriverv1alpha1.ManilaDriver, podAnnotations map[string]string, proxyEnv []corev1.EnvVar) *appsv1.DaemonSet {
 	trueVar := true
 
 	hostPathDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
 	hostPathDirectory := corev1.HostPathDirectory
 
-	return &appsv1.DaemonSet{
+	daemonSet := &appsv1.DaemonSet{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "DaemonSet",
 			APIVersion: "apps/v1",
@@ -80,7 +80,8 @@ func generateManilaNodePluginManifest() *appsv1.DaemonSet {
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labelsManilaNodePlugin,
+					Labels:      labelsManilaNodePlugin,
+					Annotations: podAnnotations,
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: "openstack-manila-csi-nodeplugin",
@@ -247,4 +248,30 @@ func generateManilaNodePluginManifest() *appsv1.DaemonSet {
 			},
 		},
 	}
+
+	// Restart the Manila plugin when its socket stops responding
+	podSpec := &daemonSet.Spec.Template.Spec
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == "nodeplugin" {
+			withLivenessProbe(&podSpec.Containers[i], manilaNodePluginHealthPort)
+			// The Manila plugin talks to OpenStack, which may be reachable only through the proxy
+			podSpec.Containers[i].Env = append(podSpec.Containers[i].Env, proxyEnv...)
+		}
+	}
+	podSpec.Containers = append(podSpec.Containers, generateLivenessProbeContainer(
+		"/csi/csi.sock",
+		manilaNodePluginHealthPort,
+		corev1.VolumeMount{
+			Name:      "plugin-dir",
+			MountPath: "/csi",
+		},
+	))
+
+	if isTopologyEnabled(instance) {
+		withTopologyArgs(&daemonSet.Spec.Template.Spec)
+	}
+
+	withNodePluginUpdateStrategy(instance, daemonSet)
+
+	return daemonSet
 }
@@ -0,0 +1,159 @@
+package maniladriver
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const prometheusRuleName = "manila-csi-driver"
+
+var (
+	prometheusRuleGVK = schema.GroupVersionKind{
+		Group:   "monitoring.coreos.com",
+		Version: "v1",
+		Kind:    "PrometheusRule",
+	}
+
+	labelsPrometheusRule = map[string]string{
+		"app":       "openstack-manila-csi",
+		"component": "alerts",
+	}
+)
+
+// manilaAlert is an alerting rule of the Manila driver
+type manilaAlert struct {
+	name        string
+	expr        string
+	duration    string
+	severity    string
+	summary     string
+	description string
+	runbook     string
+}
+
+var manilaAlerts = []manilaAlert{
+	{
+		name:        "ManilaNodePluginUnavailable",
+		expr:        `kube_daemonset_status_number_unavailable{namespace="openshift-manila-csi-driver",daemonset=~"openstack-manila-csi-nodeplugin|csi-nodeplugin-nfsplugin"} > 0`,
+		duration:    "15m",
+		severity:    "warning",
+		summary:     "Manila node plugin is not running on some nodes.",
+		description: "{{ $value }} pods of the DaemonSet {{ $labels.daemonset }} are unavailable, Manila volumes can't be mounted on their nodes.",
+		runbook:     "Find the failing pods with `oc -n openshift-manila-csi-driver get pods -o wide`, check their events and logs, and fix the problem on the affected nodes. If a rollout of the node plugins is paused, check the Degraded condition of the ManilaDriver CR.",
+	},
+	{
+		name:        "ManilaControllerPluginDown",
+		expr:        `kube_deployment_status_replicas_available{namespace="openshift-manila-csi-driver",deployment="openstack-manila-csi-controllerplugin"} == 0`,
+		duration:    "10m",
+		severity:    "critical",
+		summary:     "Manila controller plugin is down.",
+		description: "No replicas of the Manila controller plugin are available, Manila volumes can't be provisioned, expanded or deleted.",
+		runbook:     "Check the pods of the Deployment with `oc -n openshift-manila-csi-driver describe deployment openstack-manila-csi-controllerplugin` and the logs of their containers.",
+	},
+	{
+		name:        "ManilaOpenStackAuthFailures",
+		expr:        `increase(manila_operator_openstack_auth_failures_total[30m]) > 3`,
+		duration:    "5m",
+		severity:    "warning",
+		summary:     "Manila operator can't authenticate in OpenStack.",
+		description: "The operator failed to authenticate in Keystone {{ $value }} times in the last 30 minutes.",
+		runbook:     "Check the PreflightKeystoneAuth condition of the ManilaDriver CR with `oc get maniladriver cluster -o yaml` and make sure the OpenStack credentials are valid and not expired.",
+	},
+	{
+		name:        "ManilaShareTypesSyncStale",
+		expr:        `time() - manila_operator_share_types_last_sync_timestamp_seconds > 3600`,
+		duration:    "10m",
+		severity:    "warning",
+		summary:     "Manila share types have not been synchronized for more than an hour.",
+		description: "The operator has not discovered the Manila share types since {{ $value | humanizeDuration }}, StorageClasses may be out of date.",
+		runbook:     "Check the Degraded and preflight conditions of the ManilaDriver CR and the logs of the operator for errors reaching OpenStack.",
+	},
+}
+
+// handleManilaPrometheusRule manages alerts of the driver, if the monitoring API is available in the cluster
+func (r *ReconcileManilaDriver) handleManilaPrometheusRule(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) error {
+	reqLogger.Info("Reconciling Manila PrometheusRule")
+
+	rule := generateManilaPrometheusRule()
+
+	if err := annotator.SetLastAppliedAnnotation(rule); err != nil {
+		return err
+	}
+
+	// Check if this PrometheusRule already exists
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(prometheusRuleGVK)
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: rule.GetName(), Namespace: rule.GetNamespace()}, found)
+	if err != nil && errors.IsNotFound(err) {
+		reqLogger.Info("Creating a new PrometheusRule", "PrometheusRule.Namespace", rule.GetNamespace(), "PrometheusRule.Name", rule.GetName())
+		return r.client.Create(context.TODO(), rule)
+	} else if err != nil {
+		if meta.IsNoMatchError(err) {
+			// Monitoring CRDs are not installed in the cluster
+			reqLogger.Info("Skip reconcile: PrometheusRule API is not available")
+			return nil
+		}
+		return err
+	}
+
+	// Check if we need to update the object
+	equal, err := compareLastAppliedAnnotations(found, rule)
+	if err != nil {
+		return err
+	}
+
+	if !equal {
+		reqLogger.Info("Updating PrometheusRule with new changes", "PrometheusRule.Namespace", found.GetNamespace(), "PrometheusRule.Name", found.GetName())
+		rule.SetResourceVersion(found.GetResourceVersion())
+		return r.client.Update(context.TODO(), rule)
+	}
+
+	// PrometheusRule already exists - don't requeue
+	reqLogger.Info("Skip reconcile: PrometheusRule already exists", "PrometheusRule.Namespace", found.GetNamespace(), "PrometheusRule.Name", found.GetName())
+	return nil
+}
+
+func generateManilaPrometheusRule() *unstructured.Unstructured {
+	rules := make([]interface{}, 0, len(manilaAlerts))
+	for _, alert := range manilaAlerts {
+		rules = append(rules, map[string]interface{}{
+			"alert": alert.name,
+			"expr":  alert.expr,
+			"for":   alert.duration,
+			"labels": map[string]interface{}{
+				"severity": alert.severity,
+			},
+			"annotations": map[string]interface{}{
+				"summary":     alert.summary,
+				"description": alert.description,
+				"runbook":     alert.runbook,
+			},
+		})
+	}
+
+	rule := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"groups": []interface{}{
+					map[string]interface{}{
+						"name":  "manila-csi-driver",
+						"rules": rules,
+					},
+				},
+			},
+		},
+	}
+	rule.SetGroupVersionKind(prometheusRuleGVK)
+	rule.SetName(prometheusRuleName)
+	rule.SetNamespace(secretNamespace)
+	rule.SetLabels(labelsPrometheusRule)
+
+	return rule
+}
@@ -36,7 +36,6 @@ supplementalGroups:
   type: RunAsAny
 users:
 - system:serviceaccount:openshift-manila-csi-driver:csi-nodeplugin
-- system:serviceaccount:openshift-manila-csi-driver:openstack-manila-csi-controllerplugin
 - system:serviceaccount:openshift-manila-csi-driver:openstack-manila-csi-nodeplugin
 groups: []
 volumes:
@@ -49,13 +48,65 @@ volumes:
 - projected
 - secret
 `
+
+	// manilaControllerPluginSCCManifest allows the controller plugin to reach the NFS node plugin socket
+	// on the host without granting it any privileges
+	manilaControllerPluginSCCManifest = `apiVersion: security.openshift.io/v1
+kind: SecurityContextConstraints
+metadata:
+  name: csi-driver-manila-controllerplugin
+allowPrivilegedContainer: false
+allowPrivilegeEscalation: false
+allowHostDirVolumePlugin: true
+allowedCapabilities: []
+requiredDropCapabilities:
+- ALL
+allowHostIPC: false
+allowHostNetwork: false
+allowHostPID: false
+allowHostPorts: false
+runAsUser:
+  type: RunAsAny
+seLinuxContext:
+  type: RunAsAny
+fsGroup:
+  type: RunAsAny
+supplementalGroups:
+  type: RunAsAny
+users:
+- system:serviceaccount:openshift-manila-csi-driver:openstack-manila-csi-controllerplugin
+groups: []
+volumes:
+- configMap
+- downwardAPI
+- emptyDir
+- hostPath
+- projected
+- secret
+`
+
+	sccManifests = []string{
+		manilaSCCManifest,
+		manilaControllerPluginSCCManifest,
+	}
 )
 
 func (r *ReconcileManilaDriver) handleSecurityContextConstraints(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) error {
 	reqLogger.Info("Reconciling Manila Security Context Constraints")
 
+	for _, manifest := range sccManifests {
+		err := r.handleSecurityContextConstraint(manifest, reqLogger)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ReconcileManilaDriver) handleSecurityContextConstraint(manifest string, reqLogger logr.Logger) error {
 	// Define a new Security Context Constraints object
-	scc, err := generateSecurityContextConstraints()
+	scc, err := generateSecurityContextConstraints(manifest)
 	if err != nil {
 		return err
 	}
@@ -104,25 +155,27 @@ func (r *ReconcileManilaDriver) handleSecurityContextConstraints(instance *manil
 func (r *ReconcileManilaDriver) deleteSecurityContextConstraints(reqLogger logr.Logger) error {
 	reqLogger.Info("Deleting Security Context Constraints")
 
-	scc, err := generateSecurityContextConstraints()
-	if err != nil {
-		return err
-	}
+	for _, manifest := range sccManifests {
+		scc, err := generateSecurityContextConstraints(manifest)
+		if err != nil {
+			return err
+		}
 
-	err = r.client.Delete(context.TODO(), scc)
-	if err != nil {
-		return err
-	}
+		err = r.client.Delete(context.TODO(), scc)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
 
-	reqLogger.Info("Security Context Constraints were deleted succesfully", "SecurityContextConstraints.Name", scc.Name)
+		reqLogger.Info("Security Context Constraints were deleted succesfully", "SecurityContextConstraints.Name", scc.Name)
+	}
 
 	return nil
 }
 
-func generateSecurityContextConstraints() (*securityv1.SecurityContextConstraints, error) {
+func generateSecurityContextConstraints(manifest string) (*securityv1.SecurityContextConstraints, error) {
 	scc := &securityv1.SecurityContextConstraints{}
 
-	dec := k8sYaml.NewYAMLOrJSONDecoder(bytes.NewReader([]byte(manilaSCCManifest)), 1000)
+	dec := k8sYaml.NewYAMLOrJSONDecoder(bytes.NewReader([]byte(manifest)), 1000)
 	if err := dec.Decode(scc); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,151 @@
+package maniladriver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/openstack/sharedfilesystems/v2/sharenetworks"
+	"github.com/gophercloud/utils/openstack/clientconfig"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// getShareNetworkID returns the ID of the share network configured in the ManilaDriver spec.
+// When neither the share network nor the Neutron network is set, the share network attached
+// to the Neutron network of the cluster is used.
+// An empty string is returned when no share network is configured.
+func (r *ReconcileManilaDriver) getShareNetworkID(instance *maniladriverv1alpha1.ManilaDriver, cloud clientconfig.Cloud, reqLogger logr.Logger) (string, error) {
+	spec := instance.Spec.ShareNetwork
+	if spec == nil {
+		return "", nil
+	}
+
+	client, err := r.getManilaClient(instance, cloud)
+	if err != nil {
+		return "", err
+	}
+
+	if spec.ID != "" {
+		shareNetwork, err := sharenetworks.Get(client, spec.ID).Extract()
+		if err != nil {
+			return "", fmt.Errorf("failed to get Manila share network %v: %v", spec.ID, err)
+		}
+		return shareNetwork.ID, nil
+	}
+
+	neutronNetID, neutronSubnetID := spec.NeutronNetID, spec.NeutronSubnetID
+	if neutronNetID == "" && neutronSubnetID == "" {
+		neutronNetID, neutronSubnetID, err = r.getClusterNetwork(instance, cloud, reqLogger)
+		if err != nil {
+			return "", fmt.Errorf("failed to discover the Neutron network of the cluster, set shareNetwork.id, neutronNetID or neutronSubnetID: %v", err)
+		}
+	}
+
+	reqLogger.Info("Looking for Manila share network", "NeutronNetID", neutronNetID, "NeutronSubnetID", neutronSubnetID)
+
+	allPages, err := sharenetworks.ListDetail(client, sharenetworks.ListOpts{
+		NeutronNetID:    neutronNetID,
+		NeutronSubnetID: neutronSubnetID,
+	}).AllPages()
+	if err != nil {
+		return "", fmt.Errorf("failed to list Manila share networks: %v", err)
+	}
+
+	shareNetworks, err := sharenetworks.ExtractShareNetworks(allPages)
+	if err != nil {
+		return "", fmt.Errorf("failed to list Manila share networks: %v", err)
+	}
+
+	if len(shareNetworks) == 0 {
+		return "", fmt.Errorf("no Manila share network is attached to Neutron network %q and subnet %q. Create one with 'manila share-network-create'", neutronNetID, neutronSubnetID)
+	}
+
+	if len(shareNetworks) > 1 {
+		// The oldest share network is used, so the choice doesn't depend on the order returned by the API
+		sort.Slice(shareNetworks, func(i, j int) bool {
+			if !shareNetworks[i].CreatedAt.Equal(shareNetworks[j].CreatedAt) {
+				return shareNetworks[i].CreatedAt.Before(shareNetworks[j].CreatedAt)
+			}
+			return shareNetworks[i].ID < shareNetworks[j].ID
+		})
+		reqLogger.Info("Found several Manila share networks, using the oldest one", "ShareNetwork.ID", shareNetworks[0].ID)
+	}
+
+	return shareNetworks[0].ID, nil
+}
+
+// portFixedIPListOpts lists the Neutron ports with the given fixed IP address,
+// which ports.ListOpts doesn't support
+type portFixedIPListOpts struct {
+	ipAddress string
+}
+
+var _ ports.ListOptsBuilder = portFixedIPListOpts{}
+
+// ToPortListQuery implements ports.ListOptsBuilder
+func (opts portFixedIPListOpts) ToPortListQuery() (string, error) {
+	query := url.Values{}
+	query.Set("fixed_ips", "ip_address="+opts.ipAddress)
+	return "?" + query.Encode(), nil
+}
+
+// getClusterNetwork discovers the Neutron network and subnet of the cluster from the port
+// that has the internal IP address of a node
+func (r *ReconcileManilaDriver) getClusterNetwork(instance *maniladriverv1alpha1.ManilaDriver, cloud clientconfig.Cloud, reqLogger logr.Logger) (string, string, error) {
+	nodes := &corev1.NodeList{}
+	err := r.apiReader.List(context.TODO(), nodes)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Nodes are sorted by name, so the same node is used in every reconcile
+	sort.Slice(nodes.Items, func(i, j int) bool {
+		return nodes.Items[i].Name < nodes.Items[j].Name
+	})
+
+	nodeIP := ""
+	for _, node := range nodes.Items {
+		for _, address := range node.Status.Addresses {
+			if address.Type == corev1.NodeInternalIP {
+				nodeIP = address.Address
+				break
+			}
+		}
+		if nodeIP != "" {
+			break
+		}
+	}
+	if nodeIP == "" {
+		return "", "", fmt.Errorf("no node has an internal IP address")
+	}
+
+	client, err := r.getNetworkClient(instance, cloud)
+	if err != nil {
+		return "", "", err
+	}
+
+	allPages, err := ports.List(client, portFixedIPListOpts{ipAddress: nodeIP}).AllPages()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list Neutron ports: %v", err)
+	}
+
+	allPorts, err := ports.ExtractPorts(allPages)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list Neutron ports: %v", err)
+	}
+
+	for _, port := range allPorts {
+		for _, fixedIP := range port.FixedIPs {
+			if fixedIP.IPAddress == nodeIP {
+				reqLogger.Info("Discovered the Neutron network of the cluster", "NeutronNetID", port.NetworkID, "NeutronSubnetID", fixedIP.SubnetID)
+				return port.NetworkID, fixedIP.SubnetID, nil
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf("no Neutron port has the node IP address %v", nodeIP)
+}
@@ -0,0 +1,82 @@
+package maniladriver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/sharedfilesystems/v2/sharetypes"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+)
+
+// Share type extra specs describing the features of the share type
+const (
+	extraSpecDriverHandlesShareServers      = "driver_handles_share_servers"
+	extraSpecSnapshotSupport                = "snapshot_support"
+	extraSpecCreateShareFromSnapshotSupport = "create_share_from_snapshot_support"
+	extraSpecRevertToSnapshotSupport        = "revert_to_snapshot_support"
+)
+
+// shareTypeFeatures are the features of a share type available in the cloud
+type shareTypeFeatures struct {
+	// DriverHandlesShareServers means that shares of the type need a share network
+	DriverHandlesShareServers bool
+
+	Snapshots               bool
+	CreateShareFromSnapshot bool
+	RevertToSnapshot        bool
+	Expansion               bool
+}
+
+// getShareTypeFeatures returns the features advertised by the share type extra specs
+// and supported by the Manila API of the cloud
+func getShareTypeFeatures(shareType sharetypes.ShareType, capabilities maniladriverv1alpha1.ManilaCapabilities) shareTypeFeatures {
+	snapshots := capabilities.Snapshots && getExtraSpec(shareType, extraSpecSnapshotSupport)
+
+	return shareTypeFeatures{
+		DriverHandlesShareServers: getExtraSpec(shareType, extraSpecDriverHandlesShareServers),
+		Snapshots:                 snapshots,
+		CreateShareFromSnapshot:   snapshots && capabilities.CreateShareFromSnapshot && getExtraSpec(shareType, extraSpecCreateShareFromSnapshotSupport),
+		RevertToSnapshot:          snapshots && capabilities.RevertToSnapshot && getExtraSpec(shareType, extraSpecRevertToSnapshotSupport),
+		// Manila has no extra spec or pool capability for extending shares, so this only reflects
+		// the API microversion. Backends that can't extend shares fail the resize requests.
+		Expansion: capabilities.ExtendShare,
+	}
+}
+
+// names returns names of the enabled features
+func (f shareTypeFeatures) names() []string {
+	names := []string{}
+
+	features := []struct {
+		name    string
+		enabled bool
+	}{
+		{"snapshots", f.Snapshots},
+		{"createShareFromSnapshot", f.CreateShareFromSnapshot},
+		{"revertToSnapshot", f.RevertToSnapshot},
+		{"expansion", f.Expansion},
+	}
+
+	for _, feature := range features {
+		if feature.enabled {
+			names = append(names, feature.name)
+		}
+	}
+
+	return names
+}
+
+// getExtraSpec returns the boolean value of a share type extra spec. Missing extra specs are false.
+func getExtraSpec(shareType sharetypes.ShareType, name string) bool {
+	value, ok := shareType.ExtraSpecs[name]
+	if !ok {
+		value, ok = shareType.RequiredExtraSpecs[name]
+	}
+	if !ok {
+		return false
+	}
+
+	// Extra specs may be set with the scheduler syntax, like "<is> True"
+	spec := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(fmt.Sprint(value)), "<is>"))
+	return strings.EqualFold(spec, "true")
+}
@@ -2,10 +2,12 @@ package maniladriver
 
 import (
 	"context"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/gophercloud/gophercloud/openstack/sharedfilesystems/v2/sharetypes"
 	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,29 +17,84 @@ import (
 
 const (
 	storageClassNamePrefix = "csi-manila-"
+
+	// storageClassCapabilitiesAnnotation lists the Manila features available to the volumes of the StorageClass
+	storageClassCapabilitiesAnnotation = "manila.csi.openshift.io/capabilities"
+
+	// storageClassShareNetworkAnnotation is set when the share type requires a share network
+	storageClassShareNetworkAnnotation = "manila.csi.openshift.io/share-network-required"
 )
 
-func (r *ReconcileManilaDriver) handleManilaStorageClasses(instance *maniladriverv1alpha1.ManilaDriver, shareTypes []sharetypes.ShareType, reqLogger logr.Logger) error {
+func (r *ReconcileManilaDriver) handleManilaStorageClasses(instance *maniladriverv1alpha1.ManilaDriver, shareTypes []sharetypes.ShareType, shareNetworkID string, reqLogger logr.Logger) error {
 	reqLogger.Info("Reconciling Manila StorageClasses")
 
+	keep := map[string]bool{}
+	var withoutShareNetwork []string
 	for _, shareType := range shareTypes {
-		err := r.handleManilaStorageClass(instance, shareType, reqLogger)
+		if shareNetworkID == "" && getShareTypeFeatures(shareType, getCapabilities(instance)).DriverHandlesShareServers {
+			// Shares of this type can't be provisioned without a share network, so a StorageClass created
+			// with a share network that is not configured anymore is removed too
+			withoutShareNetwork = append(withoutShareNetwork, shareType.Name)
+			err := r.deleteManilaStorageClass(storageClassNamePrefix+shareType.Name, reqLogger)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := r.handleManilaStorageClass(instance, shareType, shareNetworkID, keep, reqLogger)
 		if err != nil {
 			return err
 		}
 	}
 
+	err := r.setShareNetworkMissingCondition(instance, withoutShareNetwork, reqLogger)
+	if err != nil {
+		return err
+	}
+
+	// Remove StorageClasses of availability zones that are not used anymore
+	err = r.deleteManilaZoneStorageClasses(keep, reqLogger)
+	if err != nil {
+		return err
+	}
+
+	return r.recordManilaStorageClasses()
+}
+
+// recordManilaStorageClasses reports the number of the StorageClasses managed by the operator
+func (r *ReconcileManilaDriver) recordManilaStorageClasses() error {
+	scs := &storagev1.StorageClassList{}
+	err := r.apiReader.List(context.TODO(), scs, &client.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, sc := range scs.Items {
+		if sc.Provisioner == "manila.csi.openstack.org" {
+			count++
+		}
+	}
+	storageClassesGauge.Set(float64(count))
+
 	return nil
 }
 
-func (r *ReconcileManilaDriver) handleManilaStorageClass(instance *maniladriverv1alpha1.ManilaDriver, shareType sharetypes.ShareType, reqLogger logr.Logger) error {
+// handleManilaStorageClass applies the StorageClasses of the share type and adds their names to keep
+func (r *ReconcileManilaDriver) handleManilaStorageClass(instance *maniladriverv1alpha1.ManilaDriver, shareType sharetypes.ShareType, shareNetworkID string, keep map[string]bool, reqLogger logr.Logger) error {
 	storageClassName := storageClassNamePrefix + shareType.Name
 	reqLogger.Info("Reconciling Manila StorageClass", "StorageClass.Name", storageClassName)
 
+	features := getShareTypeFeatures(shareType, getCapabilities(instance))
+
 	// Define a new StorageClass object
 	sc := &storagev1.StorageClass{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: storageClassName,
+			Annotations: map[string]string{
+				storageClassCapabilitiesAnnotation: strings.Join(features.names(), ","),
+			},
 		},
 		Provisioner: "manila.csi.openstack.org",
 		Parameters: map[string]string{
@@ -49,8 +106,68 @@ func (r *ReconcileManilaDriver) handleManilaStorageClass(instance *maniladriverv
 			"csi.storage.k8s.io/node-publish-secret-name":      "csi-manila-secrets",
 			"csi.storage.k8s.io/node-publish-secret-namespace": "openshift-manila-csi-driver",
 		},
+		MountOptions: getNFSMountOptions(instance, shareType.Name),
+	}
+
+	if features.DriverHandlesShareServers {
+		sc.Annotations[storageClassShareNetworkAnnotation] = "true"
+		sc.Parameters["shareNetworkID"] = shareNetworkID
+	}
+
+	if isVolumeExpansionEnabled(instance) && features.Expansion {
+		allowVolumeExpansion := true
+		sc.AllowVolumeExpansion = &allowVolumeExpansion
+	}
+
+	storageClasses := []*storagev1.StorageClass{sc}
+
+	if isTopologyEnabled(instance) {
+		// Create shares in the availability zone of the node that uses them
+		waitForFirstConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+		sc.VolumeBindingMode = &waitForFirstConsumer
+		sc.Parameters["autoTopology"] = "true"
+	}
+
+	if isStorageClassPerZoneEnabled(instance) {
+		for _, zone := range instance.Status.AvailabilityZones {
+			storageClasses = append(storageClasses, generateZoneStorageClass(sc, zone))
+		}
+	}
+
+	for _, storageClass := range storageClasses {
+		err := r.applyStorageClass(storageClass, reqLogger)
+		if err != nil {
+			return err
+		}
+		keep[storageClass.Name] = true
+	}
+
+	return nil
+}
+
+// generateZoneStorageClass returns a copy of the StorageClass that provisions shares in the availability zone
+func generateZoneStorageClass(sc *storagev1.StorageClass, zone string) *storagev1.StorageClass {
+	zoneSC := sc.DeepCopy()
+	zoneSC.Name = sc.Name + "-" + getZoneName(zone)
+
+	delete(zoneSC.Parameters, "autoTopology")
+	zoneSC.Parameters["availability"] = zone
+	zoneSC.AllowedTopologies = []corev1.TopologySelectorTerm{
+		{
+			MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{
+				{
+					Key:    topologyZoneKey,
+					Values: []string{zone},
+				},
+			},
+		},
 	}
 
+	return zoneSC
+}
+
+// applyStorageClass creates the StorageClass or recreates it if it has changed
+func (r *ReconcileManilaDriver) applyStorageClass(sc *storagev1.StorageClass, reqLogger logr.Logger) error {
 	if err := annotator.SetLastAppliedAnnotation(sc); err != nil {
 		return err
 	}
@@ -92,6 +209,66 @@ func (r *ReconcileManilaDriver) handleManilaStorageClass(instance *maniladriverv
 	return nil
 }
 
+// deleteManilaStorageClass deletes the StorageClass of the driver with the given name, if it exists
+func (r *ReconcileManilaDriver) deleteManilaStorageClass(name string, reqLogger logr.Logger) error {
+	found := &storagev1.StorageClass{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: name}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if found.Provisioner != "manila.csi.openstack.org" {
+		return nil
+	}
+
+	reqLogger.Info("Deleting StorageClass", "StorageClass.Name", found.Name)
+	err = r.client.Delete(context.TODO(), found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// isZoneStorageClass checks whether the StorageClass was created by the operator for an availability zone
+func isZoneStorageClass(sc *storagev1.StorageClass) bool {
+	if sc.Provisioner != "manila.csi.openstack.org" || !strings.HasPrefix(sc.Name, storageClassNamePrefix) {
+		return false
+	}
+	if _, ok := sc.Annotations[lastAppliedAnnotationName]; !ok {
+		return false
+	}
+	_, ok := sc.Parameters["availability"]
+	return ok
+}
+
+// deleteManilaZoneStorageClasses deletes StorageClasses created by the operator for availability zones, except the kept ones
+func (r *ReconcileManilaDriver) deleteManilaZoneStorageClasses(keep map[string]bool, reqLogger logr.Logger) error {
+	scs := &storagev1.StorageClassList{}
+	err := r.apiReader.List(context.TODO(), scs, &client.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for i := range scs.Items {
+		sc := &scs.Items[i]
+		if !isZoneStorageClass(sc) || keep[sc.Name] {
+			continue
+		}
+
+		reqLogger.Info("Deleting StorageClass", "StorageClass.Name", sc.Name)
+		err = r.client.Delete(context.TODO(), sc)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (r *ReconcileManilaDriver) deleteManilaStorageClasses(reqLogger logr.Logger) error {
 	reqLogger.Info("Deleting Manila StorageClasses")
 
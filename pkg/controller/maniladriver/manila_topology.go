@@ -0,0 +1,75 @@
+package maniladriver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/sharedfilesystems/v2/availabilityzones"
+	"github.com/gophercloud/utils/openstack/clientconfig"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// topologyZoneKey is the topology key reported by the Manila CSI driver for each node
+	topologyZoneKey = "topology.manila.csi.openstack.org/zone"
+)
+
+// invalidNameChars matches characters that can't be used in the names of Kubernetes objects
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// isTopologyEnabled reports whether topology-aware provisioning is enabled in the ManilaDriver spec
+func isTopologyEnabled(instance *maniladriverv1alpha1.ManilaDriver) bool {
+	return instance.Spec.Topology != nil && instance.Spec.Topology.Enabled
+}
+
+// isStorageClassPerZoneEnabled reports whether a StorageClass has to be created for each availability zone
+func isStorageClassPerZoneEnabled(instance *maniladriverv1alpha1.ManilaDriver) bool {
+	return isTopologyEnabled(instance) && instance.Spec.Topology.StorageClassPerZone
+}
+
+// getAvailabilityZones returns sorted names of the Manila availability zones
+func (r *ReconcileManilaDriver) getAvailabilityZones(instance *maniladriverv1alpha1.ManilaDriver, cloud clientconfig.Cloud) ([]string, error) {
+	client, err := r.getManilaClient(instance, cloud)
+	if err != nil {
+		return nil, err
+	}
+
+	allPages, err := availabilityzones.List(client).AllPages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Manila availability zones: %v", err)
+	}
+
+	zones, err := availabilityzones.ExtractAvailabilityZones(allPages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Manila availability zones: %v", err)
+	}
+
+	names := make([]string, 0, len(zones))
+	for _, zone := range zones {
+		names = append(names, zone.Name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// withTopologyArgs enables topology support in the Manila CSI driver containers of the pod
+func withTopologyArgs(podSpec *corev1.PodSpec) {
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		switch container.Name {
+		case "provisioner":
+			container.Args = append(container.Args, "--feature-gates=Topology=true")
+		case "nodeplugin":
+			container.Args = append(container.Args, "--with-topology")
+		}
+	}
+}
+
+// getZoneName converts the name of an availability zone into a part of Kubernetes object name
+func getZoneName(zone string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(zone), "-"), "-.")
+}
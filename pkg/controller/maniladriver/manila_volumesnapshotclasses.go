@@ -0,0 +1,168 @@
+package maniladriver
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/sharedfilesystems/v2/sharetypes"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultVolumeSnapshotClassName is the name of the VolumeSnapshotClass created in the single class mode
+	defaultVolumeSnapshotClassName = "csi-manila"
+
+	defaultVolumeSnapshotClassAnnotation = "snapshot.storage.kubernetes.io/is-default-class"
+)
+
+var (
+	volumeSnapshotClassGVK = schema.GroupVersionKind{
+		Group:   "snapshot.storage.k8s.io",
+		Version: "v1beta1",
+		Kind:    "VolumeSnapshotClass",
+	}
+
+	labelsVolumeSnapshotClass = map[string]string{
+		"app":       "openstack-manila-csi",
+		"component": "volumesnapshotclass",
+	}
+)
+
+func (r *ReconcileManilaDriver) handleManilaVolumeSnapshotClasses(instance *maniladriverv1alpha1.ManilaDriver, shareTypes []sharetypes.ShareType, reqLogger logr.Logger) error {
+	reqLogger.Info("Reconciling Manila VolumeSnapshotClasses")
+
+	desired := map[string]*unstructured.Unstructured{}
+
+	singleClass := instance.Spec.Snapshots != nil && instance.Spec.Snapshots.SingleClass
+	for _, shareType := range shareTypes {
+		if !getShareTypeFeatures(shareType, getCapabilities(instance)).Snapshots {
+			continue
+		}
+
+		if singleClass {
+			// The single VolumeSnapshotClass is created only if at least one share type supports snapshots
+			vsc := generateVolumeSnapshotClass(instance, defaultVolumeSnapshotClassName)
+			vsc.SetAnnotations(map[string]string{
+				defaultVolumeSnapshotClassAnnotation: "true",
+			})
+			desired[vsc.GetName()] = vsc
+			break
+		}
+
+		vsc := generateVolumeSnapshotClass(instance, storageClassNamePrefix+shareType.Name)
+		desired[vsc.GetName()] = vsc
+	}
+
+	for _, vsc := range desired {
+		err := r.applyVolumeSnapshotClass(vsc, reqLogger)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				// Snapshot CRDs are not installed in the cluster
+				reqLogger.Info("Skip reconcile: VolumeSnapshotClass API is not available")
+				return nil
+			}
+			return err
+		}
+	}
+
+	// Remove VolumeSnapshotClasses of share types that don't support snapshots anymore
+	return r.deleteManilaVolumeSnapshotClasses(desired, reqLogger)
+}
+
+func generateVolumeSnapshotClass(instance *maniladriverv1alpha1.ManilaDriver, name string) *unstructured.Unstructured {
+	deletionPolicy := maniladriverv1alpha1.SnapshotDeletionPolicyDelete
+	if instance.Spec.Snapshots != nil && instance.Spec.Snapshots.DeletionPolicy != "" {
+		deletionPolicy = instance.Spec.Snapshots.DeletionPolicy
+	}
+
+	vsc := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"driver":         "manila.csi.openstack.org",
+			"deletionPolicy": string(deletionPolicy),
+			"parameters": map[string]interface{}{
+				"csi.storage.k8s.io/snapshotter-secret-name":      "csi-manila-secrets",
+				"csi.storage.k8s.io/snapshotter-secret-namespace": "openshift-manila-csi-driver",
+			},
+		},
+	}
+	vsc.SetGroupVersionKind(volumeSnapshotClassGVK)
+	vsc.SetName(name)
+	vsc.SetLabels(labelsVolumeSnapshotClass)
+
+	return vsc
+}
+
+func (r *ReconcileManilaDriver) applyVolumeSnapshotClass(vsc *unstructured.Unstructured, reqLogger logr.Logger) error {
+	reqLogger.Info("Reconciling Manila VolumeSnapshotClass", "VolumeSnapshotClass.Name", vsc.GetName())
+
+	if err := annotator.SetLastAppliedAnnotation(vsc); err != nil {
+		return err
+	}
+
+	// Check if this VolumeSnapshotClass already exists
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(volumeSnapshotClassGVK)
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: vsc.GetName()}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil {
+		// Check if we need to update the object
+		equal, err := compareLastAppliedAnnotations(found, vsc)
+		if err != nil {
+			return err
+		}
+
+		if !equal {
+			// Parameters of VolumeSnapshotClass can't be updated, so we have to delete it and create again
+			reqLogger.Info("Deleting VolumeSnapshotClass", "VolumeSnapshotClass.Name", found.GetName())
+			err = r.client.Delete(context.TODO(), found)
+			if err != nil {
+				return err
+			}
+		} else {
+			// VolumeSnapshotClass already exists - don't requeue
+			reqLogger.Info("Skip reconcile: VolumeSnapshotClass already exists", "VolumeSnapshotClass.Name", found.GetName())
+			return nil
+		}
+	}
+
+	reqLogger.Info("Creating a new VolumeSnapshotClass", "VolumeSnapshotClass.Name", vsc.GetName())
+	return r.client.Create(context.TODO(), vsc)
+}
+
+// deleteManilaVolumeSnapshotClasses deletes VolumeSnapshotClasses created by the operator, except the kept ones
+func (r *ReconcileManilaDriver) deleteManilaVolumeSnapshotClasses(keep map[string]*unstructured.Unstructured, reqLogger logr.Logger) error {
+	vscs := &unstructured.UnstructuredList{}
+	vscs.SetGroupVersionKind(volumeSnapshotClassGVK.GroupVersion().WithKind("VolumeSnapshotClassList"))
+	err := r.apiReader.List(context.TODO(), vscs, client.MatchingLabels(labelsVolumeSnapshotClass))
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+
+	for i := range vscs.Items {
+		vsc := &vscs.Items[i]
+		if _, ok := keep[vsc.GetName()]; ok {
+			continue
+		}
+
+		err = r.client.Delete(context.TODO(), vsc)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+
+		reqLogger.Info("VolumeSnapshotClass was deleted succesfully", "VolumeSnapshotClass.Name", vsc.GetName())
+	}
+
+	return nil
+}
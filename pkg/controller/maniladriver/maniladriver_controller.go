@@ -2,18 +2,15 @@ package maniladriver
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"fmt"
-	"net/http"
+	"reflect"
+	"time"
 
 	"github.com/banzaicloud/k8s-objectmatcher/patch"
 	"github.com/go-logr/logr"
-	"github.com/gophercloud/gophercloud"
-	"github.com/gophercloud/gophercloud/openstack"
-	"github.com/gophercloud/gophercloud/openstack/sharedfilesystems/v2/sharetypes"
 	"github.com/gophercloud/utils/openstack/clientconfig"
 	"github.com/nsf/jsondiff"
+	configv1 "github.com/openshift/api/config/v1"
 	securityv1 "github.com/openshift/api/security/v1"
 	credsv1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
 	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
@@ -22,9 +19,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	storagev1 "k8s.io/api/storage/v1"
-	storagev1beta1 "k8s.io/api/storage/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -42,6 +42,9 @@ const (
 	manilaDriverFinalizer = "finalizer.manila.csi.openshift.io"
 
 	manilaDriverCRName = "cluster"
+
+	// shareTypesResyncPeriod defines how often the share types are discovered when nothing else triggers a reconcile
+	shareTypesResyncPeriod = 30 * time.Minute
 )
 
 var log = logf.Log.WithName("controller_maniladriver")
@@ -73,7 +76,13 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileManilaDriver{client: mgr.GetClient(), scheme: mgr.GetScheme(), apiReader: mgr.GetAPIReader()}
+	return &ReconcileManilaDriver{
+		client:     mgr.GetClient(),
+		scheme:     mgr.GetScheme(),
+		apiReader:  mgr.GetAPIReader(),
+		restMapper: mgr.GetRESTMapper(),
+		osClients:  &openStackClientCache{},
+	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -92,22 +101,28 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 
 	// Watch owned objects
 	watchOwnedObjects := []runtime.Object{
-		&appsv1.Deployment{},
-		&appsv1.DaemonSet{},
 		&corev1.Namespace{},
 		&corev1.Secret{},
 		&corev1.Service{},
-		&storagev1beta1.CSIDriver{},
 		&storagev1.StorageClass{},
 		&corev1.ServiceAccount{},
 		&rbacv1.ClusterRole{},
 		&rbacv1.ClusterRoleBinding{},
 		&rbacv1.Role{},
 		&rbacv1.RoleBinding{},
-		&credsv1.CredentialsRequest{},
 		&securityv1.SecurityContextConstraints{},
 	}
 
+	// CSIDriver is watched through the API version served by the cluster
+	csiDriver := &unstructured.Unstructured{}
+	csiDriver.SetGroupVersionKind(getCSIDriverGVK(mgr.GetRESTMapper()))
+	watchOwnedObjects = append(watchOwnedObjects, csiDriver)
+
+	// Cloud Credential Operator is not available on all clusters
+	if isKindAvailable(mgr, credsv1.SchemeGroupVersion.WithKind("CredentialsRequest")) {
+		watchOwnedObjects = append(watchOwnedObjects, &credsv1.CredentialsRequest{})
+	}
+
 	ownerHandler := &handler.EnqueueRequestForOwner{
 		IsController: true,
 		OwnerType:    &maniladriverv1alpha1.ManilaDriver{},
@@ -120,9 +135,61 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		}
 	}
 
+	// Watch the cluster-wide proxy, it's only available on OpenShift
+	if isKindAvailable(mgr, configv1.GroupVersion.WithKind("Proxy")) {
+		err = c.Watch(&source.Kind{Type: &configv1.Proxy{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &proxyMapper{},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Watch the ClusterOperator the operator reports its status to, it's only available on OpenShift
+	if isKindAvailable(mgr, configv1.GroupVersion.WithKind("ClusterOperator")) {
+		err = c.Watch(&source.Kind{Type: &configv1.ClusterOperator{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &clusterOperatorMapper{},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Watch the driver workloads, their status is reported in the ClusterOperator and the metrics
+	for _, workload := range []runtime.Object{&appsv1.Deployment{}, &appsv1.DaemonSet{}} {
+		err = c.Watch(&source.Kind{Type: workload}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &driverWorkloadMapper{},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Watch the sources of the CA certificates
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &caBundleConfigMapMapper{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch the secret with OpenStack credentials
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &credentialsSecretMapper{client: mgr.GetClient()},
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// isKindAvailable checks whether the API server serves the given kind
+func isKindAvailable(mgr manager.Manager, gvk schema.GroupVersionKind) bool {
+	_, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	return err == nil
+}
+
 // blank assignment to verify that ReconcileManilaDriver implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileManilaDriver{}
 
@@ -133,6 +200,10 @@ type ReconcileManilaDriver struct {
 	client    client.Client
 	scheme    *runtime.Scheme
 	apiReader client.Reader
+	// restMapper discovers the API versions served by the cluster
+	restMapper meta.RESTMapper
+	// osClients keeps the authenticated OpenStack client between reconciles
+	osClients *openStackClientCache
 }
 
 // Reconcile reads that state of the cluster for a ManilaDriver object and makes changes based on the state read
@@ -143,6 +214,26 @@ func (r *ReconcileManilaDriver) Reconcile(request reconcile.Request) (reconcile.
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling ManilaDriver")
 
+	result, err := r.reconcileManilaDriver(request, reqLogger)
+
+	// Publish the result in the ClusterOperator, which is watched by the cluster tooling
+	syncErr := r.syncClusterOperator(reqLogger)
+	if syncErr != nil {
+		reqLogger.Error(syncErr, "Failed to update ClusterOperator status")
+		if err == nil {
+			return reconcile.Result{}, syncErr
+		}
+	}
+
+	metricsErr := r.recordWorkloadReadiness()
+	if metricsErr != nil {
+		reqLogger.Error(metricsErr, "Failed to record workload readiness metrics")
+	}
+
+	return result, err
+}
+
+func (r *ReconcileManilaDriver) reconcileManilaDriver(request reconcile.Request, reqLogger logr.Logger) (reconcile.Result, error) {
 	// Make sure we have only one ManilaDriver instance in the system
 	driverList := &maniladriverv1alpha1.ManilaDriverList{}
 	err := r.apiReader.List(context.TODO(), driverList, &client.ListOptions{})
@@ -198,6 +289,21 @@ func (r *ReconcileManilaDriver) Reconcile(request reconcile.Request) (reconcile.
 		return reconcile.Result{}, nil
 	}
 
+	// The driver can be deployed only on OpenStack
+	err = r.checkPlatform(instance)
+	if err != nil {
+		if _, ok := err.(*unsupportedPlatformError); ok {
+			reqLogger.Info("Skip reconcile: the cluster doesn't run on OpenStack", "Reason", err.Error())
+			return reconcile.Result{}, r.setDisabledCondition(instance, err, reqLogger)
+		}
+		return reconcile.Result{}, err
+	}
+
+	err = r.clearDisabledCondition(instance, reqLogger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
 	// Add finalizer for this CR
 	if !contains(instance.GetFinalizers(), manilaDriverFinalizer) {
 		if err := r.addFinalizer(reqLogger, instance); err != nil {
@@ -211,58 +317,201 @@ func (r *ReconcileManilaDriver) Reconcile(request reconcile.Request) (reconcile.
 		return reconcile.Result{}, err
 	}
 
-	err = r.handleCACertConfigMap(instance, reqLogger)
+	err = r.handleTrustedCABundleConfigMap(instance, reqLogger)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	// Credentials Request
-	err = r.handleCredentialsRequest(instance, reqLogger)
+	err = r.handleCACertConfigMap(instance, reqLogger)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
+	err = validateCredentialsSource(instance)
+	if err != nil {
+		reqLogger.Error(err, "Invalid credentials source")
+		return r.setDegradedCondition(instance, reasonInvalidCredentialsSource, err, reqLogger)
+	}
+
+	// Credentials Request is only needed when the Cloud Credential Operator provides the credentials
+	credentialsSource := getCredentialsSource(instance)
+	if credentialsSource == maniladriverv1alpha1.CredentialsSourceCloudCredentialOperator {
+		err = r.handleCredentialsRequest(instance, reqLogger)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	} else {
+		err = r.releaseCredentialsRequest(reqLogger)
+		if err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+	}
+
 	// Get the cloud credentials
-	cloud, err := r.getCloudFromSecret()
+	cloudName := getCloudName(instance)
+	cloud, err := r.getCloud(instance)
 	if err != nil {
-		// It can take a while before the secret is created
+		recordReconcileStage(stageCredentials, err)
+		credentialsSecretName := getCredentialsSecretName(instance)
 		if errors.IsNotFound(err) {
-			reqLogger.Info(fmt.Sprintf("No %v secret was found in %v namespace. Retrying...", installerSecretName, secretNamespace))
-			return reconcile.Result{
-				RequeueAfter: 10,
-			}, nil
+			// It can take a while before the secret is created by the Cloud Credential Operator
+			if credentialsSource == maniladriverv1alpha1.CredentialsSourceCloudCredentialOperator {
+				reqLogger.Info(fmt.Sprintf("No %v secret was found in %v namespace. Retrying...", credentialsSecretName.Name, credentialsSecretName.Namespace))
+				return reconcile.Result{
+					RequeueAfter: 10,
+				}, nil
+			}
+			err = fmt.Errorf("credentials secret %v was not found in %v namespace", credentialsSecretName.Name, credentialsSecretName.Namespace)
+			reqLogger.Error(err, "Failed to read OpenStack credentials")
+			return r.setDegradedCondition(instance, reasonInvalidCredentialsSource, err, reqLogger)
 		}
-		return reconcile.Result{}, err
+		reqLogger.Error(err, "Failed to read OpenStack cloud configuration")
+		return r.setDegradedCondition(instance, reasonInvalidCloudConfig, err, reqLogger)
+	}
+
+	// Make sure the driver is able to authenticate with these credentials
+	err = validateAuthType(cloud)
+	if err != nil {
+		recordReconcileStage(stageCredentials, err)
+		reqLogger.Error(err, "Unsupported OpenStack credentials")
+		return r.setDegradedCondition(instance, reasonUnsupportedAuthType, err, reqLogger)
+	}
+
+	err = validateCloud(cloudName, cloud)
+	if err != nil {
+		recordReconcileStage(stageCredentials, err)
+		reqLogger.Error(err, "Invalid OpenStack cloud configuration")
+		return r.setDegradedCondition(instance, reasonInvalidCloudConfig, err, reqLogger)
 	}
 
 	// Driver Secret
 	err = r.createDriverCredentialsSecret(instance, cloud, reqLogger)
+	recordReconcileStage(stageCredentials, err)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	reqLogger.Info("Fetching Manila Share Types")
-	shareTypes, err := r.getManilaShareTypes(cloud, reqLogger)
+	// Make sure OpenStack is usable and fetch Manila share types
+	shareTypes, err := r.runPreflightChecks(instance, cloud, reqLogger)
+	recordReconcileStage(stageShareTypes, err)
+	if err != nil {
+		if err == errManilaNotAvailable {
+			reqLogger.Info("OpenStack Manila is not available in the cloud")
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Error(err, "OpenStack preflight checks failed")
+		return r.setDegradedCondition(instance, reasonPreflightChecksFailed, err, reqLogger)
+	}
+
+	shareTypesGauge.Set(float64(len(shareTypes)))
+	shareTypesLastSyncGauge.SetToCurrentTime()
+
+	// Share network for share types with driver_handles_share_servers=true
+	shareNetworkID, err := r.getShareNetworkID(instance, cloud, reqLogger)
 	if err != nil {
-		if _, ok := err.(gophercloud.ErrDefault404); !ok {
+		reqLogger.Error(err, "Failed to get Manila share network")
+		return r.setDegradedCondition(instance, reasonInvalidShareNetwork, err, reqLogger)
+	}
+
+	if instance.Status.ShareNetworkID != shareNetworkID {
+		instance.Status.ShareNetworkID = shareNetworkID
+		err = r.updateStatus(instance, reqLogger)
+		if err != nil {
 			return reconcile.Result{}, err
 		}
-		reqLogger.Info("OpenStack Manila is not available in the cloud")
-		return reconcile.Result{}, nil
+	}
+
+	// Manila availability zones for topology-aware StorageClasses
+	if isTopologyEnabled(instance) {
+		zones, err := r.getAvailabilityZones(instance, cloud)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+
+		if !reflect.DeepEqual(instance.Status.AvailabilityZones, zones) {
+			reqLogger.Info("Detected Manila availability zones", "AvailabilityZones", zones)
+			instance.Status.AvailabilityZones = zones
+			err = r.updateStatus(instance, reqLogger)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	}
+
+	err = validateNFSMountOptions(instance)
+	if err != nil {
+		reqLogger.Error(err, "Invalid NFS mount options")
+		return r.setDegradedCondition(instance, reasonInvalidNFSMountOptions, err, reqLogger)
 	}
 
 	// StorageClasses
-	err = r.handleManilaStorageClasses(instance, shareTypes, reqLogger)
+	err = r.handleManilaStorageClasses(instance, shareTypes, shareNetworkID, reqLogger)
+	recordReconcileStage(stageStorageClasses, err)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// VolumeSnapshotClasses
+	err = r.handleManilaVolumeSnapshotClasses(instance, shareTypes, reqLogger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Hashes of the driver configuration, which restart the driver pods when it is rotated
+	configAnnotations, err := r.getConfigHashAnnotations()
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	rotated, err := r.isConfigRotated(configAnnotations)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if rotated {
+		reqLogger.Info("Driver credentials or CA bundle were rotated, restarting the driver pods")
+		now := metav1.Now()
+		instance.Status.LastRotationTime = &now
+		err = r.updateStatus(instance, reqLogger)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	// Proxy for the driver containers that talk to OpenStack
+	proxy, err := r.getProxyConfig(instance)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
 	// Manage objects created by the operator
-	return r.handleManilariverDeployment(instance, reqLogger)
+	result, err := r.handleManilariverDeployment(instance, configAnnotations, getProxyEnv(proxy), reqLogger)
+	recordReconcileStage(stageWorkloads, err)
+	if err != nil {
+		return result, err
+	}
+
+	// Track the rollout of the node plugins
+	result, err = r.handleNodePluginRollouts(instance, reqLogger)
+	if err != nil {
+		if _, ok := err.(*rolloutFailedError); ok {
+			reqLogger.Error(err, "Node plugin rollout failed")
+			return r.setDegradedCondition(instance, reasonNodePluginRolloutFailed, err, reqLogger)
+		}
+		return result, err
+	}
+
+	// Share types are discovered only during the reconciliation, so it's repeated periodically
+	// to keep the StorageClasses in sync with Manila
+	if result == (reconcile.Result{}) {
+		result.RequeueAfter = shareTypesResyncPeriod
+	}
+
+	return result, r.clearDegradedCondition(instance, reqLogger)
 }
 
 // Manage the Objects created by the Operator.
-func (r *ReconcileManilaDriver) handleManilariverDeployment(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) (reconcile.Result, error) {
+func (r *ReconcileManilaDriver) handleManilariverDeployment(instance *maniladriverv1alpha1.ManilaDriver, configAnnotations map[string]string, proxyEnv []corev1.EnvVar, reqLogger logr.Logger) (reconcile.Result, error) {
 	reqLogger.Info("Reconciling ManilaDriver Deployment Objects")
 
 	// Security Context Constraints
@@ -295,114 +544,79 @@ func (r *ReconcileManilaDriver) handleManilariverDeployment(instance *maniladriv
 		return reconcile.Result{}, err
 	}
 
-	// Manila Controller Plugin Deployment
-	err = r.handleManilaControllerPluginDeployment(instance, reqLogger)
+	// Metrics of the sidecars. The metrics Service is created before the Deployment,
+	// so the serving certificate its pods mount is generated as soon as possible.
+	err = r.handleSidecarMetrics(instance, reqLogger)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	// Manila Node Plugin RBAC
-	err = r.handleManilaNodePluginRBAC(instance, reqLogger)
+	// Manila Controller Plugin Deployment
+	err = r.handleManilaControllerPluginDeployment(instance, configAnnotations, proxyEnv, reqLogger)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	// Manila Node Plugin DaemonSet
-	err = r.handleManilaNodePluginDaemonSet(instance, reqLogger)
+	// Manila Controller Plugin PodDisruptionBudget
+	err = r.handleManilaControllerPluginPodDisruptionBudget(instance, reqLogger)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, nil
-}
-
-// getManilaShareTypes returns all available share types
-func (r *ReconcileManilaDriver) getManilaShareTypes(cloud clientconfig.Cloud, reqLogger logr.Logger) ([]sharetypes.ShareType, error) {
-	clientOpts := new(clientconfig.ClientOpts)
-
-	if cloud.AuthInfo != nil {
-		clientOpts.AuthInfo = cloud.AuthInfo
-		clientOpts.AuthType = cloud.AuthType
-		clientOpts.Cloud = cloud.Cloud
-		clientOpts.RegionName = cloud.RegionName
-	}
-
-	opts, err := clientconfig.AuthOptions(clientOpts)
-	if err != nil {
-		return nil, err
-	}
-
-	provider, err := openstack.NewClient(opts.IdentityEndpoint)
+	// Manila Node Plugin RBAC
+	err = r.handleManilaNodePluginRBAC(instance, reqLogger)
 	if err != nil {
-		return nil, err
-	}
-
-	cert, err := r.getCloudProviderCert()
-	if err != nil && !errors.IsNotFound(err) {
-		return nil, fmt.Errorf("Failed to get cloud provider CA certificate: %v", err)
-	}
-
-	if cert != "" {
-		certPool, err := x509.SystemCertPool()
-		if err != nil {
-			return nil, fmt.Errorf("Create system cert pool failed: %v", err)
-		}
-		certPool.AppendCertsFromPEM([]byte(cert))
-		client := http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					RootCAs: certPool,
-				},
-			},
-		}
-		provider.HTTPClient = client
+		return reconcile.Result{}, err
 	}
 
-	err = openstack.Authenticate(provider, *opts)
+	// Manila Node Plugin DaemonSet
+	err = r.handleManilaNodePluginDaemonSet(instance, configAnnotations, proxyEnv, reqLogger)
 	if err != nil {
-		return nil, err
+		return reconcile.Result{}, err
 	}
 
-	client, err := openstack.NewSharedFileSystemV2(provider, gophercloud.EndpointOpts{
-		Region: clientOpts.RegionName,
-	})
+	// Alerts of the driver
+	err = r.handleManilaPrometheusRule(instance, reqLogger)
 	if err != nil {
-		return nil, err
+		return reconcile.Result{}, err
 	}
 
-	allPages, err := sharetypes.List(client, &sharetypes.ListOpts{}).AllPages()
+	// Metrics of the operator
+	err = r.handleOperatorMetrics(reqLogger)
 	if err != nil {
-		return nil, err
+		return reconcile.Result{}, err
 	}
 
-	return sharetypes.ExtractShareTypes(allPages)
+	return reconcile.Result{}, nil
 }
 
-// getCloudFromSecret extract a Cloud from the given namespace:secretName
-func (r *ReconcileManilaDriver) getCloudFromSecret() (clientconfig.Cloud, error) {
+// getCloudFromSecret extracts the cloud with the given name from clouds.yaml stored in the secret
+func (r *ReconcileManilaDriver) getCloudFromSecret(secretName types.NamespacedName, cloudName string) (clientconfig.Cloud, error) {
 	ctx := context.TODO()
 	emptyCloud := clientconfig.Cloud{}
 
 	secret := &corev1.Secret{}
-	err := r.apiReader.Get(ctx, types.NamespacedName{
-		Namespace: secretNamespace,
-		Name:      installerSecretName,
-	}, secret)
+	err := r.apiReader.Get(ctx, secretName, secret)
 	if err != nil {
 		return emptyCloud, err
 	}
 
 	content, ok := secret.Data[cloudsSecretKey]
 	if !ok {
-		return emptyCloud, fmt.Errorf("OpenStack credentials secret %v did not contain key %v", installerSecretName, cloudsSecretKey)
+		return emptyCloud, fmt.Errorf("OpenStack credentials secret %v did not contain key %v", secretName.Name, cloudsSecretKey)
 	}
 	var clouds clientconfig.Clouds
 	err = yaml.Unmarshal(content, &clouds)
 	if err != nil {
-		return emptyCloud, fmt.Errorf("failed to unmarshal clouds credentials stored in secret %v: %v", installerSecretName, err)
+		return emptyCloud, fmt.Errorf("failed to unmarshal clouds credentials stored in secret %v: %v", secretName.Name, err)
 	}
 
-	return clouds.Clouds[cloudName], nil
+	cloud, ok := clouds.Clouds[cloudName]
+	if !ok {
+		return emptyCloud, fmt.Errorf("OpenStack credentials secret %v did not contain cloud %q", secretName.Name, cloudName)
+	}
+
+	return cloud, nil
 }
 
 func (r *ReconcileManilaDriver) finalizeManilaDriver(reqLogger logr.Logger, instance *maniladriverv1alpha1.ManilaDriver) error {
@@ -421,9 +635,15 @@ func (r *ReconcileManilaDriver) finalizeManilaDriver(reqLogger logr.Logger, inst
 		return err
 	}
 
+	// Delete Volume Snapshot Classes
+	err = r.deleteManilaVolumeSnapshotClasses(nil, reqLogger)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
 	// Delete Credentials Request
 	err = r.deleteCredentialsRequest(reqLogger)
-	if err != nil && !errors.IsNotFound(err) {
+	if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
 		return err
 	}
 
@@ -471,6 +691,9 @@ func (r *ReconcileManilaDriver) finalizeManilaDriver(reqLogger logr.Logger, inst
 		return err
 	}
 
+	// Forget the OpenStack client, the credentials may be removed along with the driver
+	r.osClients.invalidate()
+
 	reqLogger.Info("Successfully finalized ManilaDriver")
 	return nil
 }
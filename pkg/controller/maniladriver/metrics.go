@@ -0,0 +1,197 @@
+package maniladriver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	"github.com/prometheus/client_golang/prometheus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Stages of the reconciliation reported in the reconcile metrics
+const (
+	stageCredentials    = "credentials"
+	stageShareTypes     = "share_types"
+	stageStorageClasses = "storage_classes"
+	stageWorkloads      = "workloads"
+)
+
+var (
+	reconcileStageTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "manila_operator_reconcile_stage_total",
+			Help: "Number of reconciliations of each stage by their outcome.",
+		},
+		[]string{"stage", "outcome"},
+	)
+
+	openStackRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "manila_operator_openstack_request_duration_seconds",
+			Help:    "Latency of the OpenStack API requests made by the operator.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"endpoint"},
+	)
+
+	openStackRequestErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "manila_operator_openstack_request_errors_total",
+			Help: "Number of the OpenStack API requests made by the operator that failed.",
+		},
+		[]string{"endpoint"},
+	)
+
+	openStackAuthFailuresTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "manila_operator_openstack_auth_failures_total",
+			Help: "Number of times the operator failed to authenticate in Keystone.",
+		},
+	)
+
+	shareTypesGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "manila_operator_share_types",
+			Help: "Number of the Manila share types discovered by the operator.",
+		},
+	)
+
+	shareTypesLastSyncGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "manila_operator_share_types_last_sync_timestamp_seconds",
+			Help: "Time of the last successful discovery of the Manila share types.",
+		},
+	)
+
+	storageClassesGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "manila_operator_storage_classes",
+			Help: "Number of the StorageClasses managed by the operator.",
+		},
+	)
+
+	workloadReadyGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "manila_operator_workload_ready",
+			Help: "Whether all pods of the driver workload are ready.",
+		},
+		[]string{"kind", "name"},
+	)
+)
+
+func init() {
+	// Register the metrics in the controller-runtime registry, which is served by the manager
+	metrics.Registry.MustRegister(
+		reconcileStageTotal,
+		openStackRequestDuration,
+		openStackRequestErrorsTotal,
+		openStackAuthFailuresTotal,
+		shareTypesGauge,
+		shareTypesLastSyncGauge,
+		storageClassesGauge,
+		workloadReadyGauge,
+	)
+}
+
+// handleOperatorMetrics allows the cluster monitoring to scrape the metrics of the operator,
+// which the alerts of the driver are based on
+func (r *ReconcileManilaDriver) handleOperatorMetrics(reqLogger logr.Logger) error {
+	reqLogger.Info("Reconciling Operator Metrics")
+
+	namespace, err := k8sutil.GetOperatorNamespace()
+	if err != nil {
+		if err == k8sutil.ErrRunLocal || err == k8sutil.ErrNoNamespace {
+			reqLogger.Info("Skip reconcile: the operator doesn't run in a cluster")
+			return nil
+		}
+		return err
+	}
+
+	ns := &corev1.Namespace{}
+	err = r.apiReader.Get(context.TODO(), types.NamespacedName{Name: namespace}, ns)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.ensureClusterMonitoringLabel(ns, reqLogger)
+	if err != nil {
+		return err
+	}
+
+	return r.handlePrometheusRBAC(namespace, reqLogger)
+}
+
+// recordReconcileStage counts the outcome of the reconciliation stage
+func recordReconcileStage(stage string, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	reconcileStageTotal.WithLabelValues(stage, outcome).Inc()
+}
+
+// instrumentedTransport measures the OpenStack API requests made by the operator
+type instrumentedTransport struct {
+	transport http.RoundTripper
+}
+
+var _ http.RoundTripper = &instrumentedTransport{}
+
+// RoundTrip implements http.RoundTripper
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host)
+
+	start := time.Now()
+	resp, err := t.transport.RoundTrip(req)
+	openStackRequestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+
+	if err != nil || resp.StatusCode >= http.StatusBadRequest {
+		openStackRequestErrorsTotal.WithLabelValues(endpoint).Inc()
+	}
+
+	return resp, err
+}
+
+// recordWorkloadReadiness reports whether all pods of the driver Deployment and DaemonSets are ready
+func (r *ReconcileManilaDriver) recordWorkloadReadiness() error {
+	deployment := &appsv1.Deployment{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: controllerPluginDeploymentName, Namespace: secretNamespace}, deployment)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if errors.IsNotFound(err) {
+		workloadReadyGauge.DeleteLabelValues("Deployment", controllerPluginDeploymentName)
+	} else {
+		workloadReadyGauge.WithLabelValues("Deployment", controllerPluginDeploymentName).Set(boolToFloat(!isDeploymentProgressing(deployment)))
+	}
+
+	for _, name := range nodePluginDaemonSetNames {
+		ds := &appsv1.DaemonSet{}
+		err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: secretNamespace}, ds)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if errors.IsNotFound(err) {
+			workloadReadyGauge.DeleteLabelValues("DaemonSet", name)
+			continue
+		}
+		workloadReadyGauge.WithLabelValues("DaemonSet", name).Set(boolToFloat(!isDaemonSetProgressing(ds)))
+	}
+
+	return nil
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,185 @@
+package maniladriver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+)
+
+// nfsMountOptionValue validates the value of an NFS mount option
+type nfsMountOptionValue func(value string) error
+
+// knownNFSMountOptions are the NFS mount options accepted in the ManilaDriver spec along with
+// the validation of their values. Options without a value are mapped to nil.
+var knownNFSMountOptions = map[string]nfsMountOptionValue{
+	"nfsvers":      oneOf("3", "4", "4.0", "4.1", "4.2"),
+	"vers":         oneOf("3", "4", "4.0", "4.1", "4.2"),
+	"proto":        oneOf("tcp", "tcp6", "rdma", "rdma6"),
+	"sec":          oneOf("sys", "krb5", "krb5i", "krb5p"),
+	"lookupcache":  oneOf("all", "none", "pos", "positive"),
+	"local_lock":   oneOf("all", "flock", "posix", "none"),
+	"timeo":        positiveNumber,
+	"retrans":      positiveNumber,
+	"rsize":        positiveNumber,
+	"wsize":        positiveNumber,
+	"acregmin":     positiveNumber,
+	"acregmax":     positiveNumber,
+	"acdirmin":     positiveNumber,
+	"acdirmax":     positiveNumber,
+	"actimeo":      positiveNumber,
+	"nconnect":     positiveNumber,
+	"retry":        positiveNumber,
+	"port":         positiveNumber,
+	"hard":         nil,
+	"soft":         nil,
+	"ac":           nil,
+	"noac":         nil,
+	"cto":          nil,
+	"nocto":        nil,
+	"lock":         nil,
+	"nolock":       nil,
+	"resvport":     nil,
+	"noresvport":   nil,
+	"sharecache":   nil,
+	"nosharecache": nil,
+	"fsc":          nil,
+	"nofsc":        nil,
+}
+
+// nfsMountOptionKeys maps aliases and mutually exclusive NFS mount options to one key, so an option
+// of a share type overrides any cluster-wide option it conflicts with
+var nfsMountOptionKeys = map[string]string{
+	"vers":         "nfsvers",
+	"soft":         "hard",
+	"noac":         "ac",
+	"nocto":        "cto",
+	"nolock":       "lock",
+	"noresvport":   "resvport",
+	"nosharecache": "sharecache",
+	"nofsc":        "fsc",
+}
+
+// getNFSMountOptionKey returns the key used to find conflicting NFS mount options
+func getNFSMountOptionKey(option string) string {
+	name, _, _ := parseNFSMountOption(option)
+	if key, ok := nfsMountOptionKeys[name]; ok {
+		return key
+	}
+	return name
+}
+
+func oneOf(values ...string) nfsMountOptionValue {
+	return func(value string) error {
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected one of %s", strings.Join(values, ", "))
+	}
+}
+
+func positiveNumber(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return fmt.Errorf("expected a positive number")
+	}
+	return nil
+}
+
+// parseNFSMountOption splits the mount option into its name and value
+func parseNFSMountOption(option string) (string, string, bool) {
+	parts := strings.SplitN(option, "=", 2)
+	if len(parts) == 1 {
+		return parts[0], "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// validateNFSMountOption checks that the mount option is known and has a valid value
+func validateNFSMountOption(option string) error {
+	name, value, hasValue := parseNFSMountOption(option)
+
+	validate, ok := knownNFSMountOptions[name]
+	if !ok {
+		return fmt.Errorf("unknown NFS mount option %q", option)
+	}
+
+	if validate == nil {
+		if hasValue {
+			return fmt.Errorf("NFS mount option %q doesn't take a value", name)
+		}
+		return nil
+	}
+
+	if !hasValue {
+		return fmt.Errorf("NFS mount option %q requires a value", name)
+	}
+
+	if err := validate(value); err != nil {
+		return fmt.Errorf("invalid value of NFS mount option %q: %v", option, err)
+	}
+
+	return nil
+}
+
+// validateNFSMountOptions checks the cluster-wide and per share type NFS mount options of the ManilaDriver
+func validateNFSMountOptions(instance *maniladriverv1alpha1.ManilaDriver) error {
+	if instance.Spec.NFS == nil {
+		return nil
+	}
+
+	for _, option := range instance.Spec.NFS.MountOptions {
+		if err := validateNFSMountOption(option); err != nil {
+			return err
+		}
+	}
+
+	shareTypes := map[string]bool{}
+	for _, shareType := range instance.Spec.NFS.ShareTypes {
+		if shareTypes[shareType.Name] {
+			return fmt.Errorf("NFS mount options of share type %q are defined more than once", shareType.Name)
+		}
+		shareTypes[shareType.Name] = true
+
+		for _, option := range shareType.MountOptions {
+			if err := validateNFSMountOption(option); err != nil {
+				return fmt.Errorf("share type %q: %v", shareType.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// getNFSMountOptions returns the mount options for volumes of the share type. Options of the share type
+// override the cluster-wide options with the same name, its aliases and the options it excludes.
+func getNFSMountOptions(instance *maniladriverv1alpha1.ManilaDriver, shareTypeName string) []string {
+	if instance.Spec.NFS == nil {
+		return nil
+	}
+
+	var shareTypeOptions []string
+	for _, shareType := range instance.Spec.NFS.ShareTypes {
+		if shareType.Name == shareTypeName {
+			shareTypeOptions = shareType.MountOptions
+			break
+		}
+	}
+
+	overridden := map[string]bool{}
+	for _, option := range shareTypeOptions {
+		overridden[getNFSMountOptionKey(option)] = true
+	}
+
+	var options []string
+	for _, option := range instance.Spec.NFS.MountOptions {
+		if !overridden[getNFSMountOptionKey(option)] {
+			options = append(options, option)
+		}
+	}
+
+	return append(options, shareTypeOptions...)
+}
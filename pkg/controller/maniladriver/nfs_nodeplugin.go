@@ -16,7 +16,7 @@ func (r *ReconcileManilaDriver) handleNFSNodePluginDaemonSet(instance *maniladri
 	reqLogger.Info("Reconciling NFS Node Plugin DaemonSet")
 
 	// Define a new DaemonSet object
-	ds := generateNFSNodePluginManifest()
+	ds := generateNFSNodePluginManifest(instance)
 
 	if err := annotator.SetLastAppliedAnnotation(ds); err != nil {
 		return err
@@ -58,7 +58,7 @@ func (r *ReconcileManilaDriver) handleNFSNodePluginDaemonSet(instance *maniladri
 	return nil
 }
 
-func generateNFSNodePluginManifest() *appsv1.DaemonSet {
+func generateNFSNodePluginManifest(instance *maniladriverv1alpha1.ManilaDriver) *appsv1.DaemonSet {
 	trueVar := true
 
 	hostPathDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
@@ -66,7 +66,7 @@ func generateNFSNodePluginManifest() *appsv1.DaemonSet {
 
 	mountPropagationBidirectional := corev1.MountPropagationBidirectional
 
-	return &appsv1.DaemonSet{
+	daemonSet := &appsv1.DaemonSet{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "DaemonSet",
 			APIVersion: "apps/v1",
@@ -151,4 +151,20 @@ func generateNFSNodePluginManifest() *appsv1.DaemonSet {
 			},
 		},
 	}
+
+	// Restart the NFS plugin when its socket stops responding
+	podSpec := &daemonSet.Spec.Template.Spec
+	withLivenessProbe(&podSpec.Containers[0], nfsNodePluginHealthPort)
+	podSpec.Containers = append(podSpec.Containers, generateLivenessProbeContainer(
+		"/plugin/csi.sock",
+		nfsNodePluginHealthPort,
+		corev1.VolumeMount{
+			Name:      "plugin-dir",
+			MountPath: "/plugin",
+		},
+	))
+
+	withNodePluginUpdateStrategy(instance, daemonSet)
+
+	return daemonSet
 }
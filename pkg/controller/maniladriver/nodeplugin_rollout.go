@@ -0,0 +1,257 @@
+package maniladriver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// nodePluginTemplateHashAnnotation identifies the pod template the node plugin pods were created from
+	nodePluginTemplateHashAnnotation = "manila.csi.openshift.io/template-hash"
+
+	// nodePluginRolloutDeadline is how long a new node plugin pod may stay not ready before
+	// the rollout is considered failed
+	nodePluginRolloutDeadline = 10 * time.Minute
+
+	// nodePluginRolloutRequeuePeriod defines how often the progress of a rollout is checked
+	nodePluginRolloutRequeuePeriod = 15 * time.Second
+)
+
+var defaultNodePluginMaxUnavailable = intstr.FromInt(1)
+
+// nodePluginDaemonSetNames are the DaemonSets whose rollout is tracked by the operator
+var nodePluginDaemonSetNames = []string{
+	"openstack-manila-csi-nodeplugin",
+	"csi-nodeplugin-nfsplugin",
+}
+
+func isNodePluginDaemonSet(name string) bool {
+	for _, dsName := range nodePluginDaemonSetNames {
+		if dsName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// rolloutFailedError is returned when new node plugin pods fail to become ready
+type rolloutFailedError struct {
+	daemonSet string
+	pods      []string
+}
+
+func (e *rolloutFailedError) Error() string {
+	return fmt.Sprintf("rollout of DaemonSet %v is paused: pods %v did not become ready within %v after they were created", e.daemonSet, e.pods, nodePluginRolloutDeadline)
+}
+
+func getNodePluginMaxUnavailable(instance *maniladriverv1alpha1.ManilaDriver) intstr.IntOrString {
+	if instance.Spec.UpdateStrategy != nil && instance.Spec.UpdateStrategy.MaxUnavailable != nil {
+		return *instance.Spec.UpdateStrategy.MaxUnavailable
+	}
+	return defaultNodePluginMaxUnavailable
+}
+
+func getCanaryNodeSelector(instance *maniladriverv1alpha1.ManilaDriver) map[string]string {
+	if instance.Spec.UpdateStrategy == nil {
+		return nil
+	}
+	return instance.Spec.UpdateStrategy.CanaryNodeSelector
+}
+
+// withNodePluginUpdateStrategy sets the update strategy of the node plugin DaemonSet and marks its pod template
+// with a hash, so the operator can tell the updated pods from the old ones.
+// With canary nodes the DaemonSet is updated on delete and the operator replaces the pods itself.
+func withNodePluginUpdateStrategy(instance *maniladriverv1alpha1.ManilaDriver, ds *appsv1.DaemonSet) {
+	if len(getCanaryNodeSelector(instance)) > 0 {
+		ds.Spec.UpdateStrategy = appsv1.DaemonSetUpdateStrategy{
+			Type: appsv1.OnDeleteDaemonSetStrategyType,
+		}
+	} else {
+		maxUnavailable := getNodePluginMaxUnavailable(instance)
+		ds.Spec.UpdateStrategy = appsv1.DaemonSetUpdateStrategy{
+			Type: appsv1.RollingUpdateDaemonSetStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateDaemonSet{
+				MaxUnavailable: &maxUnavailable,
+			},
+		}
+	}
+
+	// Pod annotations may be shared with other objects, so they are copied before modification
+	annotations := map[string]string{}
+	for key, value := range ds.Spec.Template.Annotations {
+		annotations[key] = value
+	}
+	ds.Spec.Template.Annotations = annotations
+
+	templateData, _ := json.Marshal(ds.Spec.Template)
+	annotations[nodePluginTemplateHashAnnotation] = hashData(map[string][]byte{"template": templateData})
+}
+
+// handleNodePluginRollouts tracks the rollout of the node plugin DaemonSets and requeues the reconciliation
+// until all of their pods run the current pod template
+func (r *ReconcileManilaDriver) handleNodePluginRollouts(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) (reconcile.Result, error) {
+	inProgress := false
+
+	for _, name := range nodePluginDaemonSetNames {
+		done, err := r.handleNodePluginRollout(instance, name, reqLogger)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if !done {
+			inProgress = true
+		}
+	}
+
+	if inProgress {
+		return reconcile.Result{RequeueAfter: nodePluginRolloutRequeuePeriod}, nil
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// handleNodePluginRollout checks the progress of the DaemonSet rollout and, with canary nodes,
+// replaces the old pods, first on the canary nodes and then on the others
+func (r *ReconcileManilaDriver) handleNodePluginRollout(instance *maniladriverv1alpha1.ManilaDriver, name string, reqLogger logr.Logger) (bool, error) {
+	ds := &appsv1.DaemonSet{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: secretNamespace}, ds)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	pods := &corev1.PodList{}
+	err = r.apiReader.List(context.TODO(), pods, client.InNamespace(ds.Namespace), client.MatchingLabels(ds.Spec.Selector.MatchLabels))
+	if err != nil {
+		return false, err
+	}
+
+	canaryNodes, err := r.getCanaryNodes(instance)
+	if err != nil {
+		return false, err
+	}
+
+	hash := ds.Spec.Template.Annotations[nodePluginTemplateHashAnnotation]
+
+	var stuck []string
+	var oldCanaryPods, oldPods []*corev1.Pod
+	updated, unavailable, canaryPending := 0, 0, 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil {
+			unavailable++
+			continue
+		}
+
+		ready := isPodReady(pod)
+		if !ready {
+			unavailable++
+		}
+
+		if pod.Annotations[nodePluginTemplateHashAnnotation] != hash {
+			if canaryNodes[pod.Spec.NodeName] {
+				oldCanaryPods = append(oldCanaryPods, pod)
+				canaryPending++
+			} else {
+				oldPods = append(oldPods, pod)
+			}
+			continue
+		}
+
+		updated++
+		if !ready {
+			if canaryNodes[pod.Spec.NodeName] {
+				canaryPending++
+			}
+			// The deadline applies to each new pod, so a slow rollout on many nodes doesn't fail
+			// while its pods keep becoming ready
+			if time.Since(pod.CreationTimestamp.Time) > nodePluginRolloutDeadline {
+				stuck = append(stuck, pod.Name)
+			}
+		}
+	}
+
+	// Pods that become unavailable later, for example on a node that is down, are not tracked,
+	// only the rollout of a new pod template is
+	desired := int(ds.Status.DesiredNumberScheduled)
+	if len(oldCanaryPods) == 0 && len(oldPods) == 0 && updated >= desired {
+		return true, nil
+	}
+
+	reqLogger.Info("Node plugin rollout progress", "DaemonSet.Name", ds.Name, "Desired", desired, "Updated", updated, "Unavailable", unavailable)
+
+	if len(stuck) > 0 {
+		return false, &rolloutFailedError{daemonSet: ds.Name, pods: stuck}
+	}
+
+	// Without canary nodes the DaemonSet controller replaces the pods itself
+	if ds.Spec.UpdateStrategy.Type != appsv1.OnDeleteDaemonSetStrategyType {
+		return false, nil
+	}
+
+	// The other nodes are updated only when all canary pods are updated and ready
+	candidates := oldCanaryPods
+	if canaryPending == 0 {
+		candidates = oldPods
+	}
+
+	maxUnavailable := getNodePluginMaxUnavailable(instance)
+	budget, err := intstr.GetValueFromIntOrPercent(&maxUnavailable, desired, true)
+	if err != nil {
+		return false, err
+	}
+	budget -= unavailable
+
+	for i := 0; i < budget && i < len(candidates); i++ {
+		reqLogger.Info("Deleting old node plugin pod", "Pod.Namespace", candidates[i].Namespace, "Pod.Name", candidates[i].Name, "Node.Name", candidates[i].Spec.NodeName)
+		err = r.client.Delete(context.TODO(), candidates[i])
+		if err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// getCanaryNodes returns names of the nodes selected by the canary node selector
+func (r *ReconcileManilaDriver) getCanaryNodes(instance *maniladriverv1alpha1.ManilaDriver) (map[string]bool, error) {
+	selector := getCanaryNodeSelector(instance)
+	if len(selector) == 0 {
+		return nil, nil
+	}
+
+	nodes := &corev1.NodeList{}
+	err := r.apiReader.List(context.TODO(), nodes, client.MatchingLabels(selector))
+	if err != nil {
+		return nil, err
+	}
+
+	canaryNodes := make(map[string]bool, len(nodes.Items))
+	for _, node := range nodes.Items {
+		canaryNodes[node.Name] = true
+	}
+
+	return canaryNodes, nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package maniladriver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/utils/openstack/clientconfig"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+)
+
+// authenticationError is returned when the operator can't authenticate in Keystone
+type authenticationError struct {
+	err error
+}
+
+func (e *authenticationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *authenticationError) Unwrap() error {
+	return e.err
+}
+
+// openStackClientCache keeps the authenticated OpenStack provider client between reconciles.
+// The client is identified by the hash of the credentials, the CA bundle and the proxy it was created with,
+// so it is replaced as soon as any of them changes.
+type openStackClientCache struct {
+	mu       sync.Mutex
+	key      string
+	provider *gophercloud.ProviderClient
+}
+
+func (c *openStackClientCache) get(key string) *gophercloud.ProviderClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.key != key {
+		return nil
+	}
+	return c.provider
+}
+
+func (c *openStackClientCache) set(key string, provider *gophercloud.ProviderClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.key = key
+	c.provider = provider
+}
+
+func (c *openStackClientCache) invalidate() {
+	c.set("", nil)
+}
+
+// getProviderClient returns an authenticated OpenStack provider client for the cloud.
+// The client is reused while the credentials, the CA bundle and the proxy stay the same, and the expired
+// token is renewed by gophercloud on the first unauthorized request.
+func (r *ReconcileManilaDriver) getProviderClient(instance *maniladriverv1alpha1.ManilaDriver, cloud clientconfig.Cloud) (*gophercloud.ProviderClient, error) {
+	cert, err := r.getCABundle()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get CA certificates: %v", err)
+	}
+
+	proxy, err := r.getProxyConfig(instance)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get proxy configuration: %v", err)
+	}
+
+	cloudData, err := json.Marshal(cloud)
+	if err != nil {
+		return nil, err
+	}
+	proxyData, err := json.Marshal(proxy)
+	if err != nil {
+		return nil, err
+	}
+	key := hashData(map[string][]byte{
+		"cloud":     cloudData,
+		"ca-bundle": []byte(cert),
+		"proxy":     proxyData,
+	})
+
+	if provider := r.osClients.get(key); provider != nil {
+		return provider, nil
+	}
+
+	provider, opts, err := newProviderClient(cloud, cert, proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	err = openstack.Authenticate(provider, *opts)
+	if err != nil {
+		r.osClients.invalidate()
+		openStackAuthFailuresTotal.Inc()
+		return nil, &authenticationError{err: err}
+	}
+
+	r.osClients.set(key, provider)
+
+	return provider, nil
+}
+
+// getManilaClient returns a Manila client using the microversion negotiated during the preflight checks
+func (r *ReconcileManilaDriver) getManilaClient(instance *maniladriverv1alpha1.ManilaDriver, cloud clientconfig.Cloud) (*gophercloud.ServiceClient, error) {
+	provider, err := r.getProviderClient(instance, cloud)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := openstack.NewSharedFileSystemV2(provider, gophercloud.EndpointOpts{
+		Region: cloud.RegionName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	client.Microversion = getCapabilities(instance).Microversion
+
+	return client, nil
+}
+
+func (r *ReconcileManilaDriver) getNetworkClient(instance *maniladriverv1alpha1.ManilaDriver, cloud clientconfig.Cloud) (*gophercloud.ServiceClient, error) {
+	provider, err := r.getProviderClient(instance, cloud)
+	if err != nil {
+		return nil, err
+	}
+
+	return openstack.NewNetworkV2(provider, gophercloud.EndpointOpts{
+		Region: cloud.RegionName,
+	})
+}
+
+// newProviderClient returns a not yet authenticated OpenStack provider client for the cloud
+// along with the options to authenticate it
+func newProviderClient(cloud clientconfig.Cloud, cert string, proxy maniladriverv1alpha1.ProxySpec) (*gophercloud.ProviderClient, *gophercloud.AuthOptions, error) {
+	clientOpts := new(clientconfig.ClientOpts)
+
+	if cloud.AuthInfo != nil {
+		clientOpts.AuthInfo = cloud.AuthInfo
+		clientOpts.AuthType = cloud.AuthType
+		clientOpts.Cloud = cloud.Cloud
+		clientOpts.RegionName = cloud.RegionName
+	}
+
+	opts, err := clientconfig.AuthOptions(clientOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts.AllowReauth = true
+
+	provider, err := openstack.NewClient(opts.IdentityEndpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transport := &http.Transport{
+		Proxy: getProxyFunc(proxy),
+	}
+
+	if cert != "" {
+		certPool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, nil, fmt.Errorf("Create system cert pool failed: %v", err)
+		}
+		certPool.AppendCertsFromPEM([]byte(cert))
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs: certPool,
+		}
+	}
+
+	provider.HTTPClient = http.Client{
+		Transport: &instrumentedTransport{transport: transport},
+	}
+
+	return provider, opts, nil
+}
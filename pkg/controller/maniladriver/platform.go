@@ -0,0 +1,53 @@
+package maniladriver
+
+import (
+	"context"
+	"fmt"
+
+	configv1 "github.com/openshift/api/config/v1"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// infrastructureName is the name of the Infrastructure object that describes the platform of the cluster
+const infrastructureName = "cluster"
+
+// checkPlatform returns an error describing why the driver can't be deployed on the platform of the cluster,
+// or nil if the cluster runs on OpenStack.
+// Clusters without the Infrastructure API are not OpenShift clusters and are expected to run on OpenStack.
+func (r *ReconcileManilaDriver) checkPlatform(instance *maniladriverv1alpha1.ManilaDriver) error {
+	if instance.Spec.SkipPlatformCheck {
+		return nil
+	}
+
+	infra := &configv1.Infrastructure{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: infrastructureName}, infra)
+	if err != nil {
+		if meta.IsNoMatchError(err) || errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	platform := infra.Status.Platform
+	if infra.Status.PlatformStatus != nil && infra.Status.PlatformStatus.Type != "" {
+		platform = infra.Status.PlatformStatus.Type
+	}
+
+	if platform != configv1.OpenStackPlatformType {
+		return &unsupportedPlatformError{platform: platform}
+	}
+
+	return nil
+}
+
+// unsupportedPlatformError is returned when the cluster doesn't run on OpenStack
+type unsupportedPlatformError struct {
+	platform configv1.PlatformType
+}
+
+func (e *unsupportedPlatformError) Error() string {
+	return fmt.Sprintf("the cluster runs on platform %q, the Manila CSI driver is only supported on OpenStack. Set spec.skipPlatformCheck to deploy the driver on OpenStack clusters installed with platform \"None\"", e.platform)
+}
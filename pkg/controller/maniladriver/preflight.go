@@ -0,0 +1,266 @@
+package maniladriver
+
+import (
+	"crypto/x509"
+	goerrors "errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	tokens3 "github.com/gophercloud/gophercloud/openstack/identity/v3/tokens"
+	"github.com/gophercloud/gophercloud/openstack/sharedfilesystems/apiversions"
+	"github.com/gophercloud/gophercloud/openstack/sharedfilesystems/v2/sharetypes"
+	"github.com/gophercloud/utils/openstack/clientconfig"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	reasonPreflightPassed  = "Passed"
+	reasonPreflightFailed  = "Failed"
+	reasonPreflightSkipped = "Skipped"
+
+	// manilaAPIVersion is the Manila API version used by the operator and the driver
+	manilaAPIVersion = "v2.0"
+)
+
+// errManilaNotAvailable is returned by the preflight checks when the cloud doesn't provide Manila
+var errManilaNotAvailable = goerrors.New("OpenStack Manila is not available in the cloud")
+
+// preflightCheck is a single verification of the OpenStack cloud recorded as a condition
+type preflightCheck struct {
+	conditionType status.ConditionType
+	run           func() error
+}
+
+// manilaQuotaSet contains the part of Manila project quotas used by the driver
+type manilaQuotaSet struct {
+	Shares    int `json:"shares"`
+	Gigabytes int `json:"gigabytes"`
+}
+
+// runPreflightChecks verifies that OpenStack can be used by the driver with the given credentials
+// and returns the available share types. Result of each check is recorded as a separate condition
+// in the ManilaDriver status, the checks after the first failed one are skipped.
+func (r *ReconcileManilaDriver) runPreflightChecks(instance *maniladriverv1alpha1.ManilaDriver, cloud clientconfig.Cloud, reqLogger logr.Logger) ([]sharetypes.ShareType, error) {
+	reqLogger.Info("Running OpenStack preflight checks")
+
+	var authErr error
+	provider, err := r.getProviderClient(instance, cloud)
+	if err != nil {
+		if !goerrors.As(err, new(*authenticationError)) {
+			return nil, err
+		}
+		authErr = err
+	}
+
+	authURL := cloud.AuthInfo.AuthURL
+
+	var client *gophercloud.ServiceClient
+	var shareTypes []sharetypes.ShareType
+	var capabilities *maniladriverv1alpha1.ManilaCapabilities
+
+	checks := []preflightCheck{
+		{
+			conditionType: maniladriverv1alpha1.ConditionPreflightTLSTrust,
+			run: func() error {
+				if isCertificateError(authErr) {
+					return fmt.Errorf("the certificate of Keystone endpoint %v is not trusted: %v. Add the CA certificate of the cloud to the ca-bundle.pem key of the cloud-provider-config ConfigMap in the openshift-config namespace, or to the trusted CA bundle of the cluster-wide proxy", authURL, authErr)
+				}
+				return nil
+			},
+		},
+		{
+			conditionType: maniladriverv1alpha1.ConditionPreflightKeystoneAuth,
+			run: func() error {
+				if authErr != nil {
+					return fmt.Errorf("failed to authenticate in Keystone %v: %v. Make sure the OpenStack credentials are valid and not expired", authURL, authErr)
+				}
+				return nil
+			},
+		},
+		{
+			conditionType: maniladriverv1alpha1.ConditionPreflightManilaEndpoint,
+			run: func() error {
+				client, err = openstack.NewSharedFileSystemV2(provider, gophercloud.EndpointOpts{
+					Region: cloud.RegionName,
+				})
+				if err != nil {
+					return fmt.Errorf("Shared File Systems service (sharev2) was not found in the service catalog of region %q: %v. Make sure Manila is deployed in the cloud and the user has access to it", cloud.RegionName, err)
+				}
+				return nil
+			},
+		},
+		{
+			conditionType: maniladriverv1alpha1.ConditionPreflightManilaAPIVersion,
+			run: func() error {
+				version, err := checkManilaAPIVersion(client)
+				if err != nil {
+					return err
+				}
+
+				capabilities, err = discoverManilaCapabilities(version)
+				if err != nil {
+					return err
+				}
+				client.Microversion = capabilities.Microversion
+				return nil
+			},
+		},
+		{
+			conditionType: maniladriverv1alpha1.ConditionPreflightShareTypes,
+			run: func() error {
+				shareTypes, err = listManilaShareTypes(client)
+				if err != nil {
+					return fmt.Errorf("failed to list Manila share types: %v. Make sure the user has the member role in the project", err)
+				}
+				if len(shareTypes) == 0 {
+					return fmt.Errorf("no Manila share types are available to the project. Create a public share type or grant the project access to an existing one")
+				}
+				return nil
+			},
+		},
+		{
+			conditionType: maniladriverv1alpha1.ConditionPreflightQuotas,
+			run: func() error {
+				return checkManilaQuotas(provider, client)
+			},
+		},
+	}
+
+	var checkErr error
+	var failedCheck status.ConditionType
+	changed := false
+	for i, check := range checks {
+		checkErr = check.run()
+		changed = setPreflightCondition(instance, check.conditionType, checkErr) || changed
+		if checkErr != nil {
+			reqLogger.Info("OpenStack preflight check failed", "Check", check.conditionType, "Error", checkErr.Error())
+			failedCheck = check.conditionType
+			for _, skipped := range checks[i+1:] {
+				changed = skipPreflightCondition(instance, skipped.conditionType, check.conditionType) || changed
+			}
+			break
+		}
+	}
+
+	if capabilities != nil && !reflect.DeepEqual(capabilities, instance.Status.Capabilities) {
+		reqLogger.Info("Detected Manila capabilities", "Microversion", capabilities.Microversion)
+		instance.Status.Capabilities = capabilities
+		changed = true
+	}
+
+	if changed {
+		if err := r.updateStatus(instance, reqLogger); err != nil {
+			return nil, err
+		}
+	}
+
+	if checkErr != nil {
+		if failedCheck == maniladriverv1alpha1.ConditionPreflightManilaEndpoint {
+			return nil, errManilaNotAvailable
+		}
+		return nil, checkErr
+	}
+
+	return shareTypes, nil
+}
+
+// checkManilaAPIVersion makes sure that Manila serves the API version used by the driver and returns it
+func checkManilaAPIVersion(client *gophercloud.ServiceClient) (*apiversions.APIVersion, error) {
+	allPages, err := apiversions.List(client).AllPages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Manila API versions: %v", err)
+	}
+
+	versions, err := apiversions.ExtractAPIVersions(allPages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Manila API versions: %v", err)
+	}
+
+	for i := range versions {
+		if versions[i].ID == manilaAPIVersion && (versions[i].Status == "CURRENT" || versions[i].Status == "SUPPORTED") {
+			return &versions[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("Manila API %v is not available. Upgrade Manila to a release that supports the %v API", manilaAPIVersion, manilaAPIVersion)
+}
+
+// listManilaShareTypes returns all share types available to the project
+func listManilaShareTypes(client *gophercloud.ServiceClient) ([]sharetypes.ShareType, error) {
+	allPages, err := sharetypes.List(client, &sharetypes.ListOpts{}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+
+	return sharetypes.ExtractShareTypes(allPages)
+}
+
+// checkManilaQuotas makes sure that the project quotas allow creating shares
+func checkManilaQuotas(provider *gophercloud.ProviderClient, client *gophercloud.ServiceClient) error {
+	authResult, ok := provider.GetAuthResult().(tokens3.CreateResult)
+	if !ok {
+		return fmt.Errorf("failed to get the project of the OpenStack user: Keystone v3 token is required")
+	}
+
+	project, err := authResult.ExtractProject()
+	if err != nil || project == nil {
+		return fmt.Errorf("failed to get the project of the OpenStack user: the credentials must be scoped to a project")
+	}
+
+	var result struct {
+		QuotaSet manilaQuotaSet `json:"quota_set"`
+	}
+	_, err = client.Get(client.ServiceURL("quota-sets", project.ID), &result, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get Manila quotas of project %v: %v. Make sure the user is allowed to show quotas of the project", project.ID, err)
+	}
+
+	if result.QuotaSet.Shares == 0 || result.QuotaSet.Gigabytes == 0 {
+		return fmt.Errorf("Manila quotas of project %v don't allow creating shares (shares: %v, gigabytes: %v). Ask the cloud administrator to increase them", project.ID, result.QuotaSet.Shares, result.QuotaSet.Gigabytes)
+	}
+
+	return nil
+}
+
+// isCertificateError reports whether the error is caused by an untrusted server certificate
+func isCertificateError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certificateInvalidErr x509.CertificateInvalidError
+
+	return goerrors.As(err, &unknownAuthorityErr) || goerrors.As(err, &hostnameErr) || goerrors.As(err, &certificateInvalidErr)
+}
+
+func setPreflightCondition(instance *maniladriverv1alpha1.ManilaDriver, conditionType status.ConditionType, err error) bool {
+	condition := status.Condition{
+		Type:   conditionType,
+		Status: corev1.ConditionTrue,
+		Reason: reasonPreflightPassed,
+	}
+
+	if err != nil {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = reasonPreflightFailed
+		condition.Message = err.Error()
+	}
+
+	return instance.Status.Conditions.SetCondition(condition)
+}
+
+func skipPreflightCondition(instance *maniladriverv1alpha1.ManilaDriver, conditionType, failedConditionType status.ConditionType) bool {
+	return instance.Status.Conditions.SetCondition(status.Condition{
+		Type:    conditionType,
+		Status:  corev1.ConditionUnknown,
+		Reason:  reasonPreflightSkipped,
+		Message: fmt.Sprintf("The check was skipped because %v failed", failedConditionType),
+	})
+}
@@ -0,0 +1,95 @@
+package maniladriver
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	configv1 "github.com/openshift/api/config/v1"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	"golang.org/x/net/http/httpproxy"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	clusterProxyName = "cluster"
+)
+
+// getProxyConfig returns the proxy configured in the ManilaDriver spec, or the cluster-wide proxy if it's not set
+func (r *ReconcileManilaDriver) getProxyConfig(instance *maniladriverv1alpha1.ManilaDriver) (maniladriverv1alpha1.ProxySpec, error) {
+	if instance.Spec.Proxy != nil {
+		return *instance.Spec.Proxy, nil
+	}
+
+	proxy := &configv1.Proxy{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: clusterProxyName}, proxy)
+	if err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			// The cluster doesn't use a proxy
+			return maniladriverv1alpha1.ProxySpec{}, nil
+		}
+		return maniladriverv1alpha1.ProxySpec{}, err
+	}
+
+	return maniladriverv1alpha1.ProxySpec{
+		HTTPProxy:  proxy.Status.HTTPProxy,
+		HTTPSProxy: proxy.Status.HTTPSProxy,
+		NoProxy:    proxy.Status.NoProxy,
+	}, nil
+}
+
+// getProxyEnv returns environment variables that make the driver use the proxy
+func getProxyEnv(proxy maniladriverv1alpha1.ProxySpec) []corev1.EnvVar {
+	env := []corev1.EnvVar{}
+
+	for _, v := range []corev1.EnvVar{
+		{Name: "HTTP_PROXY", Value: proxy.HTTPProxy},
+		{Name: "HTTPS_PROXY", Value: proxy.HTTPSProxy},
+		{Name: "NO_PROXY", Value: proxy.NoProxy},
+	} {
+		if v.Value != "" {
+			env = append(env, v)
+		}
+	}
+
+	return env
+}
+
+// getProxyFunc returns the proxy function for the operator's HTTP transport
+func getProxyFunc(proxy maniladriverv1alpha1.ProxySpec) func(*http.Request) (*url.URL, error) {
+	if proxy.HTTPProxy == "" && proxy.HTTPSProxy == "" {
+		return http.ProxyFromEnvironment
+	}
+
+	config := httpproxy.Config{
+		HTTPProxy:  proxy.HTTPProxy,
+		HTTPSProxy: proxy.HTTPSProxy,
+		NoProxy:    proxy.NoProxy,
+	}
+	proxyFunc := config.ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+}
+
+// proxyMapper enqueues the ManilaDriver when the cluster-wide proxy changes
+type proxyMapper struct{}
+
+var _ handler.Mapper = &proxyMapper{}
+
+// Map implements handler.Mapper
+func (m *proxyMapper) Map(obj handler.MapObject) []reconcile.Request {
+	if obj.Meta.GetName() != clusterProxyName {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: manilaDriverCRName}},
+	}
+}
@@ -0,0 +1,396 @@
+package maniladriver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// The sidecars serve plain HTTP metrics on localhost, which are exposed over TLS by kube-rbac-proxy.
+// Only the controller plugin sidecars are exposed: node-driver-registrar serves no metrics and container ports
+// of the host network node plugin pods would become host ports.
+const (
+	provisionerMetricsPort       = int32(8221)
+	provisionerSecureMetricsPort = int32(9221)
+	snapshotterMetricsPort       = int32(8222)
+	snapshotterSecureMetricsPort = int32(9222)
+	resizerMetricsPort           = int32(8223)
+	resizerSecureMetricsPort     = int32(9223)
+
+	controllerPluginMetricsServiceName    = "openstack-manila-csi-controllerplugin-metrics"
+	controllerPluginMetricsCertSecretName = "manila-csi-controllerplugin-metrics-serving-cert"
+
+	// servingCertSecretAnnotation requests a TLS certificate for the Service from the service CA operator
+	servingCertSecretAnnotation = "service.beta.openshift.io/serving-cert-secret-name"
+
+	metricsCertVolumeName = "metrics-serving-cert"
+	metricsCertMountPath  = "/etc/tls/private"
+
+	// prometheusServiceAccount is the service account of the cluster monitoring Prometheus
+	prometheusServiceAccount = "prometheus-k8s"
+	prometheusNamespace      = "openshift-monitoring"
+	prometheusRoleName       = "manila-csi-driver-prometheus"
+
+	// clusterMonitoringNamespace labels the namespaces scraped by the cluster monitoring
+	clusterMonitoringNamespace = "openshift.io/cluster-monitoring"
+)
+
+var serviceMonitorGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "ServiceMonitor",
+}
+
+// sidecarMetrics describes the metrics endpoint of a CSI sidecar
+type sidecarMetrics struct {
+	// name of the sidecar container, which is also used to name its kube-rbac-proxy and Service port
+	name string
+	// flag that configures the metrics address of the sidecar
+	flag       string
+	port       int32
+	securePort int32
+}
+
+var controllerPluginSidecarMetrics = []sidecarMetrics{
+	{name: "provisioner", flag: "--metrics-address", port: provisionerMetricsPort, securePort: provisionerSecureMetricsPort},
+	{name: "snapshotter", flag: "--metrics-address", port: snapshotterMetricsPort, securePort: snapshotterSecureMetricsPort},
+	{name: "resizer", flag: "--metrics-address", port: resizerMetricsPort, securePort: resizerSecureMetricsPort},
+}
+
+func (m sidecarMetrics) portName() string {
+	return m.name + "-m"
+}
+
+// withSidecarMetrics enables metrics of the sidecars deployed in the pod and exposes them with kube-rbac-proxy
+func withSidecarMetrics(podSpec *corev1.PodSpec, metrics []sidecarMetrics, certSecretName string, securityContext *corev1.SecurityContext) {
+	var proxies []corev1.Container
+
+	for _, m := range metrics {
+		for i := range podSpec.Containers {
+			container := &podSpec.Containers[i]
+			if container.Name != m.name {
+				continue
+			}
+
+			container.Args = append(container.Args, fmt.Sprintf("%s=127.0.0.1:%d", m.flag, m.port))
+			proxy := generateKubeRBACProxyContainer(m)
+			proxy.SecurityContext = securityContext
+			proxies = append(proxies, proxy)
+		}
+	}
+
+	// The certificate is generated by the service CA operator, which is only available on OpenShift.
+	// Without it only the proxies fail, the driver containers still start.
+	optional := true
+	podSpec.Containers = append(podSpec.Containers, proxies...)
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: metricsCertVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: certSecretName,
+				Optional:   &optional,
+			},
+		},
+	})
+}
+
+// generateKubeRBACProxyContainer returns a container that serves the sidecar metrics over TLS
+// to the clients authorized to read them
+func generateKubeRBACProxyContainer(m sidecarMetrics) corev1.Container {
+	return corev1.Container{
+		Name:  m.name + "-kube-rbac-proxy",
+		Image: getKubeRBACProxyImage(),
+		Args: []string{
+			fmt.Sprintf("--secure-listen-address=0.0.0.0:%d", m.securePort),
+			fmt.Sprintf("--upstream=http://127.0.0.1:%d/", m.port),
+			"--tls-cert-file=" + metricsCertMountPath + "/tls.crt",
+			"--tls-private-key-file=" + metricsCertMountPath + "/tls.key",
+			"--logtostderr=true",
+		},
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          m.portName(),
+				ContainerPort: m.securePort,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		},
+		ImagePullPolicy: "IfNotPresent",
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      metricsCertVolumeName,
+				MountPath: metricsCertMountPath,
+			},
+		},
+	}
+}
+
+// handleSidecarMetrics manages the Services and ServiceMonitors of the sidecar metrics and allows
+// the cluster monitoring to scrape them
+func (r *ReconcileManilaDriver) handleSidecarMetrics(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) error {
+	reqLogger.Info("Reconciling Sidecar Metrics")
+
+	err := r.handlePrometheusRBAC(secretNamespace, reqLogger)
+	if err != nil {
+		return err
+	}
+
+	controllerPluginMetrics := getEnabledSidecarMetrics(generateManilaControllerPluginDeployment(instance, nil, nil).Spec.Template.Spec, controllerPluginSidecarMetrics)
+
+	services := []*corev1.Service{
+		generateMetricsService(controllerPluginMetricsServiceName, controllerPluginMetricsCertSecretName, labelsManilaControllerPlugin, controllerPluginMetrics),
+	}
+
+	serviceMonitorsAvailable := true
+	for _, service := range services {
+		err = r.applyMetricsService(service, reqLogger)
+		if err != nil {
+			return err
+		}
+
+		if !serviceMonitorsAvailable {
+			continue
+		}
+
+		err = r.applyServiceMonitor(generateServiceMonitor(service), reqLogger)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				// Monitoring CRDs are not installed in the cluster, the Services are still created
+				reqLogger.Info("Skip reconcile: ServiceMonitor API is not available")
+				serviceMonitorsAvailable = false
+				continue
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getEnabledSidecarMetrics returns the metrics of the sidecars that are deployed in the pod
+func getEnabledSidecarMetrics(podSpec corev1.PodSpec, metrics []sidecarMetrics) []sidecarMetrics {
+	var enabled []sidecarMetrics
+	for _, m := range metrics {
+		for _, container := range podSpec.Containers {
+			if container.Name == m.name+"-kube-rbac-proxy" {
+				enabled = append(enabled, m)
+			}
+		}
+	}
+	return enabled
+}
+
+func generateMetricsService(name, certSecretName string, selector map[string]string, metrics []sidecarMetrics) *corev1.Service {
+	ports := make([]corev1.ServicePort, 0, len(metrics))
+	for _, m := range metrics {
+		ports = append(ports, corev1.ServicePort{
+			Name:       m.portName(),
+			Port:       m.securePort,
+			TargetPort: intstr.FromString(m.portName()),
+			Protocol:   corev1.ProtocolTCP,
+		})
+	}
+
+	labels := map[string]string{}
+	for key, value := range selector {
+		labels[key] = value
+	}
+	labels["metrics"] = name
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: secretNamespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				servingCertSecretAnnotation: certSecretName,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports:    ports,
+		},
+	}
+}
+
+func (r *ReconcileManilaDriver) applyMetricsService(service *corev1.Service, reqLogger logr.Logger) error {
+	if err := annotator.SetLastAppliedAnnotation(service); err != nil {
+		return err
+	}
+
+	// Check if this Service already exists
+	found := &corev1.Service{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		reqLogger.Info("Creating a new Service", "Service.Namespace", service.Namespace, "Service.Name", service.Name)
+		return r.client.Create(context.TODO(), service)
+	} else if err != nil {
+		return err
+	}
+
+	// Check if we need to update the object
+	equal, err := compareLastAppliedAnnotations(found, service)
+	if err != nil {
+		return err
+	}
+
+	if !equal {
+		reqLogger.Info("Updating Service with new changes", "Service.Namespace", found.Namespace, "Service.Name", found.Name)
+		// The cluster IP is immutable and the serving cert annotations are set by the service CA operator
+		service.ResourceVersion = found.ResourceVersion
+		service.Spec.ClusterIP = found.Spec.ClusterIP
+		for key, value := range found.Annotations {
+			if _, ok := service.Annotations[key]; !ok {
+				service.Annotations[key] = value
+			}
+		}
+		return r.client.Update(context.TODO(), service)
+	}
+
+	// Service already exists - don't requeue
+	reqLogger.Info("Skip reconcile: Service already exists", "Service.Namespace", found.Namespace, "Service.Name", found.Name)
+	return nil
+}
+
+func generateServiceMonitor(service *corev1.Service) *unstructured.Unstructured {
+	endpoints := make([]interface{}, 0, len(service.Spec.Ports))
+	for _, port := range service.Spec.Ports {
+		endpoints = append(endpoints, map[string]interface{}{
+			"port":            port.Name,
+			"scheme":          "https",
+			"path":            "/metrics",
+			"interval":        "30s",
+			"bearerTokenFile": "/var/run/secrets/kubernetes.io/serviceaccount/token",
+			"tlsConfig": map[string]interface{}{
+				"caFile":     "/etc/prometheus/configmaps/serving-certs-ca-bundle/service-ca.crt",
+				"serverName": fmt.Sprintf("%s.%s.svc", service.Name, service.Namespace),
+			},
+		})
+	}
+
+	sm := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"endpoints": endpoints,
+				"jobLabel":  "component",
+				"selector": map[string]interface{}{
+					"matchLabels": map[string]interface{}{
+						"metrics": service.Name,
+					},
+				},
+			},
+		},
+	}
+	sm.SetGroupVersionKind(serviceMonitorGVK)
+	sm.SetName(service.Name)
+	sm.SetNamespace(service.Namespace)
+	sm.SetLabels(service.Labels)
+
+	return sm
+}
+
+func (r *ReconcileManilaDriver) applyServiceMonitor(sm *unstructured.Unstructured, reqLogger logr.Logger) error {
+	if err := annotator.SetLastAppliedAnnotation(sm); err != nil {
+		return err
+	}
+
+	// Check if this ServiceMonitor already exists
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(serviceMonitorGVK)
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: sm.GetName(), Namespace: sm.GetNamespace()}, found)
+	if err != nil && errors.IsNotFound(err) {
+		reqLogger.Info("Creating a new ServiceMonitor", "ServiceMonitor.Namespace", sm.GetNamespace(), "ServiceMonitor.Name", sm.GetName())
+		return r.client.Create(context.TODO(), sm)
+	} else if err != nil {
+		return err
+	}
+
+	// Check if we need to update the object
+	equal, err := compareLastAppliedAnnotations(found, sm)
+	if err != nil {
+		return err
+	}
+
+	if !equal {
+		reqLogger.Info("Updating ServiceMonitor with new changes", "ServiceMonitor.Namespace", found.GetNamespace(), "ServiceMonitor.Name", found.GetName())
+		sm.SetResourceVersion(found.GetResourceVersion())
+		return r.client.Update(context.TODO(), sm)
+	}
+
+	// ServiceMonitor already exists - don't requeue
+	reqLogger.Info("Skip reconcile: ServiceMonitor already exists", "ServiceMonitor.Namespace", found.GetNamespace(), "ServiceMonitor.Name", found.GetName())
+	return nil
+}
+
+// handlePrometheusRBAC allows the cluster monitoring Prometheus to discover the metrics endpoints in the namespace
+func (r *ReconcileManilaDriver) handlePrometheusRBAC(namespace string, reqLogger logr.Logger) error {
+	role := generatePrometheusRole(namespace)
+	found := &rbacv1.Role{}
+	err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: role.Name, Namespace: role.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		reqLogger.Info("Creating a new Role", "Role.Namespace", role.Namespace, "Role.Name", role.Name)
+		err = r.client.Create(context.TODO(), role)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	roleBinding := generatePrometheusRoleBinding(namespace)
+	foundRoleBinding := &rbacv1.RoleBinding{}
+	err = r.apiReader.Get(context.TODO(), types.NamespacedName{Name: roleBinding.Name, Namespace: roleBinding.Namespace}, foundRoleBinding)
+	if err != nil && errors.IsNotFound(err) {
+		reqLogger.Info("Creating a new RoleBinding", "RoleBinding.Namespace", roleBinding.Namespace, "RoleBinding.Name", roleBinding.Name)
+		return r.client.Create(context.TODO(), roleBinding)
+	}
+
+	return err
+}
+
+func generatePrometheusRole(namespace string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      prometheusRoleName,
+			Namespace: namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"services", "endpoints", "pods"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+		},
+	}
+}
+
+func generatePrometheusRoleBinding(namespace string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      prometheusRoleName,
+			Namespace: namespace,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      prometheusServiceAccount,
+				Namespace: prometheusNamespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			Kind:     "Role",
+			Name:     prometheusRoleName,
+			APIGroup: "rbac.authorization.k8s.io",
+		},
+	}
+}
@@ -0,0 +1,131 @@
+package maniladriver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	maniladriverv1alpha1 "github.com/openshift/csi-driver-manila-operator/pkg/apis/maniladriver/v1alpha1"
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// degradedRequeuePeriod defines how often a degraded ManilaDriver is reconciled again, because
+	// many failures come from OpenStack, like an unreachable Manila API or missing share types,
+	// and no Kubernetes event signals that they have been fixed
+	degradedRequeuePeriod = time.Minute
+
+	reasonAsExpected          = "AsExpected"
+	reasonInvalidCloudConfig  = "InvalidCloudConfig"
+	reasonUnsupportedAuthType = "UnsupportedAuthType"
+
+	reasonInvalidCredentialsSource  = "InvalidCredentialsSource"
+	reasonPreflightChecksFailed     = "PreflightChecksFailed"
+	reasonInvalidShareNetwork       = "InvalidShareNetwork"
+	reasonInvalidNFSMountOptions    = "InvalidNFSMountOptions"
+	reasonNodePluginRolloutFailed   = "NodePluginRolloutFailed"
+	reasonUnsupportedPlatform       = "UnsupportedPlatform"
+	reasonShareNetworkNotConfigured = "ShareNetworkNotConfigured"
+)
+
+// setDegradedCondition marks the ManilaDriver as degraded, stores the reason in its status
+// and schedules a new reconciliation
+func (r *ReconcileManilaDriver) setDegradedCondition(instance *maniladriverv1alpha1.ManilaDriver, reason string, err error, reqLogger logr.Logger) (reconcile.Result, error) {
+	changed := instance.Status.Conditions.SetCondition(status.Condition{
+		Type:    maniladriverv1alpha1.ConditionDegraded,
+		Status:  corev1.ConditionTrue,
+		Reason:  status.ConditionReason(reason),
+		Message: err.Error(),
+	})
+	if changed {
+		if err := r.updateStatus(instance, reqLogger); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	return reconcile.Result{RequeueAfter: degradedRequeuePeriod}, nil
+}
+
+// clearDegradedCondition marks the ManilaDriver as not degraded
+func (r *ReconcileManilaDriver) clearDegradedCondition(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) error {
+	changed := instance.Status.Conditions.SetCondition(status.Condition{
+		Type:   maniladriverv1alpha1.ConditionDegraded,
+		Status: corev1.ConditionFalse,
+		Reason: reasonAsExpected,
+	})
+	if !changed {
+		return nil
+	}
+
+	return r.updateStatus(instance, reqLogger)
+}
+
+// setDisabledCondition marks the ManilaDriver as disabled on the platform of the cluster
+func (r *ReconcileManilaDriver) setDisabledCondition(instance *maniladriverv1alpha1.ManilaDriver, err error, reqLogger logr.Logger) error {
+	changed := instance.Status.Conditions.SetCondition(status.Condition{
+		Type:    maniladriverv1alpha1.ConditionDisabled,
+		Status:  corev1.ConditionTrue,
+		Reason:  reasonUnsupportedPlatform,
+		Message: err.Error(),
+	})
+	if !changed {
+		return nil
+	}
+
+	return r.updateStatus(instance, reqLogger)
+}
+
+// clearDisabledCondition marks the ManilaDriver as enabled
+func (r *ReconcileManilaDriver) clearDisabledCondition(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) error {
+	if instance.Status.Conditions.GetCondition(maniladriverv1alpha1.ConditionDisabled) == nil {
+		return nil
+	}
+
+	changed := instance.Status.Conditions.SetCondition(status.Condition{
+		Type:   maniladriverv1alpha1.ConditionDisabled,
+		Status: corev1.ConditionFalse,
+		Reason: reasonAsExpected,
+	})
+	if !changed {
+		return nil
+	}
+
+	return r.updateStatus(instance, reqLogger)
+}
+
+// setShareNetworkMissingCondition reports the share types whose StorageClasses are not created,
+// because they require a share network
+func (r *ReconcileManilaDriver) setShareNetworkMissingCondition(instance *maniladriverv1alpha1.ManilaDriver, shareTypes []string, reqLogger logr.Logger) error {
+	condition := status.Condition{
+		Type:   maniladriverv1alpha1.ConditionShareNetworkMissing,
+		Status: corev1.ConditionFalse,
+		Reason: reasonAsExpected,
+	}
+	if len(shareTypes) > 0 {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = reasonShareNetworkNotConfigured
+		condition.Message = fmt.Sprintf("share types %v require a share network, configure it in spec.shareNetwork to create their StorageClasses", shareTypes)
+	}
+
+	changed := instance.Status.Conditions.SetCondition(condition)
+	if !changed {
+		return nil
+	}
+
+	return r.updateStatus(instance, reqLogger)
+}
+
+func (r *ReconcileManilaDriver) updateStatus(instance *maniladriverv1alpha1.ManilaDriver, reqLogger logr.Logger) error {
+	reqLogger.Info("Updating ManilaDriver status")
+
+	err := r.client.Status().Update(context.TODO(), instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update ManilaDriver status")
+		return err
+	}
+
+	return nil
+}